@@ -0,0 +1,101 @@
+package studiomodel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/galaco/studiomodel/phy"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// CollisionSolid is a phy solid paired with the bone it is attached to
+type CollisionSolid struct {
+	// Index
+	// Index of the solid in the phy
+	Index int
+	// Bone
+	// Index of the bone in the mdl, -1 if it could not be resolved
+	Bone int
+	// BoneName
+	BoneName string
+	// Properties
+	// Text section properties of the solid, nil if absent
+	Properties *phy.Solid
+	// Hulls
+	// Convex pieces in Source units, in model or posed space
+	Hulls []phy.Hull
+}
+
+// CollisionSolids returns every collision solid in model space, using the bind pose
+func (model *StudioModel) CollisionSolids() ([]CollisionSolid, error) {
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to place collision solids")
+	}
+	return model.PosedCollisionSolids(model.Mdl.BindPose())
+}
+
+// PosedCollisionSolids returns every collision solid transformed by a posed skeleton.
+// bones holds the bone-to-space transform of every mdl bone, in Source units.
+func (model *StudioModel) PosedCollisionSolids(bones []mgl32.Mat4) ([]CollisionSolid, error) {
+	if model.Phy == nil {
+		return nil, errors.New("model has no collision model")
+	}
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to place collision solids")
+	}
+	if len(bones) != len(model.Mdl.Bones) {
+		return nil, fmt.Errorf("got %d bone transforms, mdl has %d bones", len(bones), len(model.Mdl.Bones))
+	}
+
+	solids := make([]CollisionSolid, len(model.Phy.Surfaces))
+	for i := range model.Phy.Surfaces {
+		solid := model.resolveSolid(i)
+
+		transform := mgl32.Ident4()
+		if solid.Bone >= 0 {
+			transform = bones[solid.Bone]
+		}
+
+		hulls := model.Phy.Surfaces[i].Hulls()
+		solid.Hulls = make([]phy.Hull, len(hulls))
+		for j := range hulls {
+			solid.Hulls[j] = hulls[j].Transform(func(v mgl32.Vec3) mgl32.Vec3 {
+				return transform.Mul4x1(phy.ToSourceSpace(v).Vec4(1)).Vec3()
+			})
+		}
+
+		solids[i] = solid
+	}
+
+	return solids, nil
+}
+
+// resolveSolid pairs a solid with its text properties and bone.
+// The text "index" key identifies the solid; studiomdl also writes the solid index into
+// each ledge's client data, which is used when the two disagree in ordering.
+func (model *StudioModel) resolveSolid(index int) CollisionSolid {
+	solid := CollisionSolid{
+		Index: index,
+		Bone:  -1,
+	}
+
+	properties, ok := model.Phy.SolidByIndex(index)
+	if !ok && len(model.Phy.Surfaces[index].Ledges) > 0 {
+		properties, ok = model.Phy.SolidByIndex(int(model.Phy.Surfaces[index].Ledges[0].ClientData))
+	}
+	if ok {
+		solid.Properties = properties
+		solid.BoneName = properties.Name
+		solid.Bone = model.Mdl.BoneIndex(properties.Name)
+	}
+
+	// Single solid props are attached to the root bone
+	if solid.Bone < 0 && len(model.Phy.Surfaces) == 1 && len(model.Mdl.Bones) > 0 {
+		solid.Bone = 0
+		if solid.BoneName == "" && len(model.Mdl.BoneNames) > 0 {
+			solid.BoneName = model.Mdl.BoneNames[0]
+		}
+	}
+
+	return solid
+}
@@ -0,0 +1,184 @@
+package studiomodel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/galaco/studiomodel/mdl"
+	"github.com/galaco/studiomodel/phy"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ivpCubeSurface returns a compact surface of one ledge holding cubeHull(center, extent),
+// stored in IVP space like a phy
+func ivpCubeSurface(center mgl32.Vec3, extent float32, clientData int32) phy.CompactSurface {
+	hull := cubeHull(center, extent)
+	surface := phy.CompactSurface{Ledges: []phy.Ledge{{ClientData: clientData}}}
+	for _, v := range hull.Vertices {
+		surface.Vertices = append(surface.Vertices, phy.FromSourceSpace(v))
+	}
+	for _, triangle := range hull.Triangles {
+		surface.Ledges[0].Triangles = append(surface.Ledges[0].Triangles, phy.Triangle{Vertices: triangle})
+	}
+	return surface
+}
+
+// twoBoneMdl has a root bone at the origin and an "arm" bone 10 units up, turned 90 degrees
+// about Z so its X axis points along model Y
+func twoBoneMdl() *mdl.Mdl {
+	return &mdl.Mdl{
+		Bones: []mdl.Bone{
+			{Parent: -1, PoseToBone: mgl32.Mat3x4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0}},
+			// Row-major inverse of the bone-to-model transform
+			{Parent: 0, PoseToBone: mgl32.Mat3x4{0, 1, 0, 0, -1, 0, 0, 0, 0, 0, 1, -10}},
+		},
+		BoneNames: []string{"root", "Arm"},
+	}
+}
+
+// hullBounds returns the bounds of every vertex of a solid
+func hullBounds(solid CollisionSolid) (lo, hi mgl32.Vec3) {
+	lo = mgl32.Vec3{1e9, 1e9, 1e9}
+	hi = mgl32.Vec3{-1e9, -1e9, -1e9}
+	for _, hull := range solid.Hulls {
+		for _, v := range hull.Vertices {
+			for axis := 0; axis < 3; axis++ {
+				lo[axis] = min(lo[axis], v[axis])
+				hi[axis] = max(hi[axis], v[axis])
+			}
+		}
+	}
+	return lo, hi
+}
+
+func TestBindPose(t *testing.T) {
+	pose := twoBoneMdl().BindPose()
+	cases := []struct {
+		bone        int
+		point, want mgl32.Vec3
+	}{
+		{0, mgl32.Vec3{1, 2, 3}, mgl32.Vec3{1, 2, 3}},
+		{1, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 0, 10}},
+		{1, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 10}},
+		{1, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{-1, 0, 10}},
+	}
+	for _, tc := range cases {
+		if got := pose[tc.bone].Mul4x1(tc.point.Vec4(1)).Vec3(); !got.ApproxEqualThreshold(tc.want, 1e-5) {
+			t.Errorf("bone %d maps %v to %v, want %v", tc.bone, tc.point, got, tc.want)
+		}
+	}
+}
+
+func TestCollisionSolids(t *testing.T) {
+	// Both cubes sit 1 unit along X of their bone
+	armCube := ivpCubeSurface(mgl32.Vec3{1, 0, 0}, 1, 1)
+	rootCube := ivpCubeSurface(mgl32.Vec3{1, 0, 0}, 1, 0)
+
+	type want struct {
+		bone     int
+		boneName string
+		min, max mgl32.Vec3
+	}
+	// Bounds of a cube on each bone, and unplaced
+	onArm := func(name string) want {
+		return want{1, name, mgl32.Vec3{-1, 0, 9}, mgl32.Vec3{1, 2, 11}}
+	}
+	onRoot := func(name string) want {
+		return want{0, name, mgl32.Vec3{0, -1, -1}, mgl32.Vec3{2, 1, 1}}
+	}
+	unplaced := want{-1, "", mgl32.Vec3{0, -1, -1}, mgl32.Vec3{2, 1, 1}}
+
+	cases := []struct {
+		name     string
+		surfaces []phy.CompactSurface
+		solids   []phy.Solid
+		want     []want
+	}{
+		{
+			// Client data is ignored when the text index matches
+			name:     "text index",
+			surfaces: []phy.CompactSurface{rootCube, armCube},
+			solids:   []phy.Solid{{Index: 1, Name: "root"}, {Index: 0, Name: "arm"}},
+			want:     []want{onArm("arm"), onRoot("root")},
+		},
+		{
+			// The text indices are out of range, so each solid falls back to its client data
+			name:     "client data fallback",
+			surfaces: []phy.CompactSurface{ivpCubeSurface(mgl32.Vec3{1, 0, 0}, 1, 3), ivpCubeSurface(mgl32.Vec3{1, 0, 0}, 1, 2)},
+			solids:   []phy.Solid{{Index: 2, Name: "root"}, {Index: 3, Name: "arm"}},
+			want:     []want{onArm("arm"), onRoot("root")},
+		},
+		{
+			name:     "single solid without text",
+			surfaces: []phy.CompactSurface{armCube},
+			want:     []want{onRoot("root")},
+		},
+		{
+			name:     "single solid with unknown bone",
+			surfaces: []phy.CompactSurface{armCube},
+			solids:   []phy.Solid{{Index: 0, Name: "prop"}},
+			want:     []want{onRoot("prop")},
+		},
+		{
+			name:     "unresolved solids",
+			surfaces: []phy.CompactSurface{armCube, rootCube},
+			want:     []want{unplaced, unplaced},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &StudioModel{Mdl: twoBoneMdl(), Phy: &phy.Phy{Surfaces: tc.surfaces, Solids: tc.solids}}
+
+			solids, err := model.CollisionSolids()
+			if err != nil {
+				t.Fatalf("CollisionSolids failed: %v", err)
+			}
+			if len(solids) != len(tc.want) {
+				t.Fatalf("got %d solids, want %d", len(solids), len(tc.want))
+			}
+			for i, solid := range solids {
+				if solid.Index != i || solid.Bone != tc.want[i].bone || solid.BoneName != tc.want[i].boneName {
+					t.Errorf("solid %d is on bone %d %q, want %d %q", i, solid.Bone, solid.BoneName, tc.want[i].bone, tc.want[i].boneName)
+				}
+				if (solid.Properties != nil) != (len(tc.solids) > 0) {
+					t.Errorf("solid %d properties = %+v", i, solid.Properties)
+				}
+				if len(solid.Hulls) != 1 || len(solid.Hulls[0].Triangles) != 12 {
+					t.Fatalf("solid %d hulls = %+v", i, solid.Hulls)
+				}
+				if lo, hi := hullBounds(solid); !lo.ApproxEqualThreshold(tc.want[i].min, 1e-4) ||
+					!hi.ApproxEqualThreshold(tc.want[i].max, 1e-4) {
+					t.Errorf("solid %d bounds = %v %v, want %v %v", i, lo, hi, tc.want[i].min, tc.want[i].max)
+				}
+			}
+		})
+	}
+}
+
+func TestPosedCollisionSolidsErrors(t *testing.T) {
+	surfaces := []phy.CompactSurface{ivpCubeSurface(mgl32.Vec3{}, 1, 0)}
+
+	cases := []struct {
+		name  string
+		model *StudioModel
+		bones []mgl32.Mat4
+		want  string
+	}{
+		{"no phy", &StudioModel{Mdl: twoBoneMdl()}, twoBoneMdl().BindPose(), "no collision model"},
+		{"no mdl", &StudioModel{Phy: &phy.Phy{Surfaces: surfaces}}, nil, "requires an mdl"},
+		{"bone count", &StudioModel{Mdl: twoBoneMdl(), Phy: &phy.Phy{Surfaces: surfaces}}, []mgl32.Mat4{mgl32.Ident4()},
+			"got 1 bone transforms, mdl has 2 bones"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.model.PosedCollisionSolids(tc.bones); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	if _, err := (&StudioModel{}).CollisionSolids(); err == nil {
+		t.Error("expected an error for a model without an mdl")
+	}
+}
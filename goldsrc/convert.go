@@ -0,0 +1,287 @@
+package goldsrc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// RenderMesh is a flattened, indexed triangle mesh ready for upload to a renderer.
+// Positions and normals are in model space for the pose it was built with.
+type RenderMesh struct {
+	// Texture
+	// Index into Mdl.Textures after skin family resolution
+	Texture int
+	// Positions
+	Positions []mgl32.Vec3
+	// Normals
+	Normals []mgl32.Vec3
+	// UVs
+	UVs []mgl32.Vec2
+	// Bones
+	// Bone each vertex is attached to
+	Bones []uint8
+	// Indices
+	// Triangle list indices into the vertex arrays
+	Indices []uint32
+}
+
+// Skeleton returns the model space transform of every bone in the bind pose
+func (mdl *Mdl) Skeleton() []mgl32.Mat4 {
+	local := make([]mgl32.Mat4, len(mdl.Bones))
+	for i, bone := range mdl.Bones {
+		local[i] = boneMatrix(
+			mgl32.Vec3{bone.Value[0], bone.Value[1], bone.Value[2]},
+			mgl32.Vec3{bone.Value[3], bone.Value[4], bone.Value[5]})
+	}
+	return mdl.concatenate(local)
+}
+
+// Pose returns the model space transform of every bone for a frame of a sequence.
+// Only the first blend of the sequence is evaluated.
+func (mdl *Mdl) Pose(sequence int, frame int) ([]mgl32.Mat4, error) {
+	if sequence < 0 || sequence >= len(mdl.Sequences) {
+		return nil, fmt.Errorf("sequence index %d out of range (have %d sequences)", sequence, len(mdl.Sequences))
+	}
+	desc := &mdl.Sequences[sequence]
+	if desc.NumFrames > 0 {
+		frame = frame % int(desc.NumFrames)
+		if frame < 0 {
+			frame += int(desc.NumFrames)
+		}
+	}
+
+	group := int(desc.SequenceGroup)
+	if group < 0 || group >= len(mdl.animationData) || mdl.animationData[group] == nil {
+		return nil, fmt.Errorf("sequence %d uses sequence group %d, which has not been attached", sequence, group)
+	}
+	data := mdl.animationData[group]
+
+	// mstudioanim_t is an array of 6 offsets per bone
+	animSize := int32(MaxNumBoneControllers * 2)
+	local := make([]mgl32.Mat4, len(mdl.Bones))
+	for i := range mdl.Bones {
+		bone := &mdl.Bones[i]
+		animOffset := desc.AnimIndex + int32(i)*animSize
+
+		var offsets [MaxNumBoneControllers]uint16
+		if err := readStructs(data, animOffset, &offsets, "bone animation"); err != nil {
+			return nil, fmt.Errorf("bone %d: %w", i, err)
+		}
+
+		var channels [MaxNumBoneControllers]float32
+		for k := 0; k < MaxNumBoneControllers; k++ {
+			channels[k] = bone.Value[k]
+			if offsets[k] == 0 {
+				continue
+			}
+			value, err := decodeAnimValue(data, animOffset+int32(offsets[k]), frame)
+			if err != nil {
+				return nil, fmt.Errorf("bone %d channel %d: %w", i, k, err)
+			}
+			channels[k] += float32(value) * bone.Scale[k]
+		}
+
+		local[i] = boneMatrix(
+			mgl32.Vec3{channels[0], channels[1], channels[2]},
+			mgl32.Vec3{channels[3], channels[4], channels[5]})
+	}
+
+	return mdl.concatenate(local), nil
+}
+
+// Meshes builds render meshes for a model in the bind pose
+func (mdl *Mdl) Meshes(bodyPart int, model int, skinFamily int) ([]RenderMesh, error) {
+	return mdl.PosedMeshes(bodyPart, model, skinFamily, mdl.Skeleton())
+}
+
+// PosedMeshes builds render meshes for a model, using the provided model space bone transforms
+func (mdl *Mdl) PosedMeshes(bodyPart int, model int, skinFamily int, bones []mgl32.Mat4) ([]RenderMesh, error) {
+	if bodyPart < 0 || bodyPart >= len(mdl.BodyParts) {
+		return nil, fmt.Errorf("body part index %d out of range (have %d body parts)", bodyPart, len(mdl.BodyParts))
+	}
+	if model < 0 || model >= len(mdl.BodyParts[bodyPart].Models) {
+		return nil, fmt.Errorf("model index %d out of range in body part %d (have %d models)", model, bodyPart, len(mdl.BodyParts[bodyPart].Models))
+	}
+	if len(bones) != len(mdl.Bones) {
+		return nil, fmt.Errorf("got %d bone transforms, model has %d bones", len(bones), len(mdl.Bones))
+	}
+
+	modelData := &mdl.BodyParts[bodyPart].Models[model]
+
+	// Transform vertices and normals out of bone space once
+	positions := make([]mgl32.Vec3, len(modelData.Vertices))
+	for i, v := range modelData.Vertices {
+		bone := int(modelData.VertexBones[i])
+		if bone >= len(bones) {
+			return nil, fmt.Errorf("vertex %d references bone %d (have %d bones)", i, bone, len(bones))
+		}
+		positions[i] = bones[bone].Mul4x1(v.Vec4(1)).Vec3()
+	}
+	normals := make([]mgl32.Vec3, len(modelData.Normals))
+	for i, n := range modelData.Normals {
+		bone := int(modelData.NormalBones[i])
+		if bone >= len(bones) {
+			return nil, fmt.Errorf("normal %d references bone %d (have %d bones)", i, bone, len(bones))
+		}
+		normals[i] = bones[bone].Mul4x1(n.Vec4(0)).Vec3().Normalize()
+	}
+
+	meshes := make([]RenderMesh, len(modelData.Meshes))
+	for i, mesh := range modelData.Meshes {
+		textureIndex, err := mdl.textureForSkinReference(int(mesh.Header.SkinReference), skinFamily)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d: %w", i, err)
+		}
+
+		width, height := float32(1), float32(1)
+		if textureIndex >= 0 && textureIndex < len(mdl.Textures) {
+			width = float32(mdl.Textures[textureIndex].Header.Width)
+			height = float32(mdl.Textures[textureIndex].Header.Height)
+		}
+
+		out := RenderMesh{Texture: textureIndex}
+		for _, command := range mesh.Commands {
+			base := uint32(len(out.Positions))
+			for _, v := range command.Vertices {
+				if int(v.VertexIndex) >= len(positions) || int(v.NormalIndex) >= len(normals) || v.VertexIndex < 0 || v.NormalIndex < 0 {
+					return nil, fmt.Errorf("mesh %d references vertex %d/normal %d out of range", i, v.VertexIndex, v.NormalIndex)
+				}
+				out.Positions = append(out.Positions, positions[v.VertexIndex])
+				out.Normals = append(out.Normals, normals[v.NormalIndex])
+				out.Bones = append(out.Bones, modelData.VertexBones[v.VertexIndex])
+				out.UVs = append(out.UVs, mgl32.Vec2{float32(v.S) / width, float32(v.T) / height})
+			}
+
+			for j := uint32(2); j < uint32(len(command.Vertices)); j++ {
+				switch {
+				case command.Fan:
+					out.Indices = append(out.Indices, base, base+j-1, base+j)
+				case j%2 == 0:
+					out.Indices = append(out.Indices, base+j-2, base+j-1, base+j)
+				default:
+					out.Indices = append(out.Indices, base+j-1, base+j-2, base+j)
+				}
+			}
+		}
+		meshes[i] = out
+	}
+
+	return meshes, nil
+}
+
+// Images returns every embedded texture as a paletted image
+func (mdl *Mdl) Images() []image.Image {
+	images := make([]image.Image, len(mdl.Textures))
+	for i := range mdl.Textures {
+		images[i] = mdl.Textures[i].Image()
+	}
+	return images
+}
+
+// Image returns the texture as a paletted image.
+// Masked textures use palette entry 255 as transparent.
+func (texture *TextureData) Image() *image.Paletted {
+	palette := make(color.Palette, len(texture.Palette))
+	for i, c := range texture.Palette {
+		palette[i] = color.RGBA{R: c[0], G: c[1], B: c[2], A: 255}
+	}
+	if texture.Header.Flags&TextureMasked != 0 {
+		palette[255] = color.RGBA{}
+	}
+
+	width, height := int(texture.Header.Width), int(texture.Header.Height)
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	copy(img.Pix, texture.Pixels)
+	return img
+}
+
+// textureForSkinReference resolves a mesh skin reference through a skin family
+func (mdl *Mdl) textureForSkinReference(skinReference int, skinFamily int) (int, error) {
+	if len(mdl.Skins) == 0 {
+		return skinReference, nil
+	}
+	if skinFamily < 0 || skinFamily >= len(mdl.Skins) {
+		return 0, fmt.Errorf("skin family %d out of range (have %d families)", skinFamily, len(mdl.Skins))
+	}
+	if skinReference < 0 || skinReference >= len(mdl.Skins[skinFamily]) {
+		return 0, fmt.Errorf("skin reference %d out of range (have %d references)", skinReference, len(mdl.Skins[skinFamily]))
+	}
+	return int(mdl.Skins[skinFamily][skinReference]), nil
+}
+
+// concatenate walks the bone hierarchy turning parent relative transforms into model space ones
+func (mdl *Mdl) concatenate(local []mgl32.Mat4) []mgl32.Mat4 {
+	world := make([]mgl32.Mat4, len(local))
+	for i, bone := range mdl.Bones {
+		// Parents always precede their children in GoldSrc models
+		if bone.Parent >= 0 && int(bone.Parent) < i {
+			world[i] = world[bone.Parent].Mul4(local[i])
+		} else {
+			world[i] = local[i]
+		}
+	}
+	return world
+}
+
+// decodeAnimValue decodes a run length encoded mstudioanimvalue_t stream for a frame
+func decodeAnimValue(data []byte, offset int32, frame int) (int16, error) {
+	read := func(index int32) (valid uint8, total uint8, value int16, err error) {
+		pos := offset + index*2
+		if err = validateOffset(data, pos, 2, "animation value"); err != nil {
+			return
+		}
+		valid, total = data[pos], data[pos+1]
+		err = binary.Read(bytes.NewReader(data[pos:pos+2]), binary.LittleEndian, &value)
+		return
+	}
+
+	index := int32(0)
+	k := frame
+	valid, total, _, err := read(index)
+	if err != nil {
+		return 0, err
+	}
+	for int(total) <= k {
+		if total == 0 {
+			return 0, fmt.Errorf("malformed animation value run at offset %d", offset+index*2)
+		}
+		k -= int(total)
+		index += int32(valid) + 1
+		if valid, total, _, err = read(index); err != nil {
+			return 0, err
+		}
+	}
+
+	if int(valid) > k {
+		_, _, value, err := read(index + int32(k) + 1)
+		return value, err
+	}
+	_, _, value, err := read(index + int32(valid))
+	return value, err
+}
+
+// boneMatrix builds a transform from a position and GoldSrc euler angles (roll, pitch, yaw in radians)
+func boneMatrix(position mgl32.Vec3, angles mgl32.Vec3) mgl32.Mat4 {
+	sr, cr := math.Sincos(float64(angles[0]) * 0.5)
+	sp, cp := math.Sincos(float64(angles[1]) * 0.5)
+	sy, cy := math.Sincos(float64(angles[2]) * 0.5)
+
+	q := mgl32.Quat{
+		W: float32(cr*cp*cy + sr*sp*sy),
+		V: mgl32.Vec3{
+			float32(sr*cp*cy - cr*sp*sy),
+			float32(cr*sp*cy + sr*cp*sy),
+			float32(cr*cp*sy - sr*sp*cy),
+		},
+	}
+
+	m := q.Mat4()
+	m.SetCol(3, position.Vec4(1))
+	return m
+}
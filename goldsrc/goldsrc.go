@@ -0,0 +1,477 @@
+package goldsrc
+
+import "github.com/go-gl/mathgl/mgl32"
+
+const (
+	// MaxNumBoneControllers is the number of controller channels per bone (X, Y, Z, XR, YR, ZR)
+	MaxNumBoneControllers = 6
+)
+
+const (
+	// TextureFlatShade
+	TextureFlatShade = 0x0001
+	// TextureChrome
+	TextureChrome = 0x0002
+	// TextureFullBright
+	TextureFullBright = 0x0004
+	// TextureNoMips
+	TextureNoMips = 0x0008
+	// TextureAlpha
+	TextureAlpha = 0x0010
+	// TextureAdditive
+	TextureAdditive = 0x0020
+	// TextureMasked
+	TextureMasked = 0x0040
+)
+
+// Header is the GoldSrc studiohdr_t. Contains offsets and info for different data in this file.
+type Header struct {
+	// Id
+	Id int32
+	// Version
+	Version int32
+	// Name
+	// 64 char exactly, null byte padded
+	Name [64]byte
+	// Length
+	Length int32
+
+	// EyePosition
+	EyePosition mgl32.Vec3
+	// Min
+	Min mgl32.Vec3
+	// Max
+	Max mgl32.Vec3
+	// BBMin
+	BBMin mgl32.Vec3
+	// BBMax
+	BBMax mgl32.Vec3
+
+	// Flags
+	Flags int32
+
+	// BoneCount
+	BoneCount int32
+	// BoneOffset
+	BoneOffset int32
+
+	// BoneControllerCount
+	BoneControllerCount int32
+	// BoneControllerOffset
+	BoneControllerOffset int32
+
+	// HitboxCount
+	HitboxCount int32
+	// HitboxOffset
+	HitboxOffset int32
+
+	// SequenceCount
+	SequenceCount int32
+	// SequenceOffset
+	SequenceOffset int32
+
+	// SequenceGroupCount
+	SequenceGroupCount int32
+	// SequenceGroupOffset
+	SequenceGroupOffset int32
+
+	// TextureCount
+	TextureCount int32
+	// TextureOffset
+	TextureOffset int32
+	// TextureDataOffset
+	TextureDataOffset int32
+
+	// SkinReferenceCount
+	SkinReferenceCount int32
+	// SkinFamilyCount
+	SkinFamilyCount int32
+	// SkinOffset
+	SkinOffset int32
+
+	// BodyPartCount
+	BodyPartCount int32
+	// BodyPartOffset
+	BodyPartOffset int32
+
+	// AttachmentCount
+	AttachmentCount int32
+	// AttachmentOffset
+	AttachmentOffset int32
+
+	// SoundTable
+	SoundTable int32
+	// SoundOffset
+	SoundOffset int32
+	// SoundGroups
+	SoundGroups int32
+	// SoundGroupOffset
+	SoundGroupOffset int32
+
+	// TransitionCount
+	TransitionCount int32
+	// TransitionOffset
+	TransitionOffset int32
+}
+
+// SequenceHeader is the studioseqhdr_t found at the start of external sequence group files (*01.mdl etc.)
+type SequenceHeader struct {
+	// Id
+	Id int32
+	// Version
+	Version int32
+	// Name
+	Name [64]byte
+	// Length
+	Length int32
+}
+
+// Bone
+type Bone struct {
+	// Name
+	Name [32]byte
+	// Parent
+	Parent int32
+	// Flags
+	Flags int32
+	// BoneController
+	BoneController [MaxNumBoneControllers]int32
+	// Value
+	// Default position (0-2) and euler rotation (3-5)
+	Value [MaxNumBoneControllers]float32
+	// Scale
+	// Scale applied to compressed animation values per channel
+	Scale [MaxNumBoneControllers]float32
+}
+
+// BoneController
+type BoneController struct {
+	// Bone
+	Bone int32
+	// Type
+	Type int32
+	// Start
+	Start float32
+	// End
+	End float32
+	// Rest
+	Rest int32
+	// Index
+	Index int32
+}
+
+// Hitbox
+type Hitbox struct {
+	// Bone
+	Bone int32
+	// Group
+	Group int32
+	// BBMin
+	BBMin mgl32.Vec3
+	// BBMax
+	BBMax mgl32.Vec3
+}
+
+// SequenceGroup describes where a group of sequences stores its animation data.
+// Group 0 is always the model itself; other groups live in external files.
+type SequenceGroup struct {
+	// Label
+	Label [32]byte
+	// Name
+	// File name of the external group, e.g. models/scientist01.mdl
+	Name [64]byte
+	_    [2]int32
+}
+
+// SequenceDesc
+type SequenceDesc struct {
+	// Label
+	Label [32]byte
+
+	// Fps
+	Fps float32
+	// Flags
+	Flags int32
+
+	// Activity
+	Activity int32
+	// ActivityWeight
+	ActivityWeight int32
+
+	// NumEvents
+	NumEvents int32
+	// EventIndex
+	EventIndex int32
+
+	// NumFrames
+	NumFrames int32
+
+	// NumPivots
+	NumPivots int32
+	// PivotIndex
+	PivotIndex int32
+
+	// MotionType
+	MotionType int32
+	// MotionBone
+	MotionBone int32
+	// LinearMovement
+	LinearMovement mgl32.Vec3
+	// AutoMovePosIndex
+	AutoMovePosIndex int32
+	// AutoMoveAngleIndex
+	AutoMoveAngleIndex int32
+
+	// BBMin
+	BBMin mgl32.Vec3
+	// BBMax
+	BBMax mgl32.Vec3
+
+	// NumBlends
+	NumBlends int32
+	// AnimIndex
+	// Offset of the mstudioanim_t array, relative to the start of the owning sequence group
+	AnimIndex int32
+
+	// BlendType
+	BlendType [2]int32
+	// BlendStart
+	BlendStart [2]float32
+	// BlendEnd
+	BlendEnd [2]float32
+	// BlendParent
+	BlendParent int32
+
+	// SequenceGroup
+	SequenceGroup int32
+
+	// EntryNode
+	EntryNode int32
+	// ExitNode
+	ExitNode int32
+	// NodeFlags
+	NodeFlags int32
+
+	// NextSequence
+	NextSequence int32
+}
+
+// Texture
+type Texture struct {
+	// Name
+	Name [64]byte
+	// Flags
+	Flags int32
+	// Width
+	Width int32
+	// Height
+	Height int32
+	// Index
+	// Offset of the 8-bit pixel data, followed by a 256 entry RGB palette
+	Index int32
+}
+
+// BodyPart
+type BodyPart struct {
+	// Name
+	Name [64]byte
+	// NumModels
+	NumModels int32
+	// Base
+	Base int32
+	// ModelIndex
+	ModelIndex int32
+}
+
+// Model
+type Model struct {
+	// Name
+	Name [64]byte
+	// Type
+	Type int32
+	// BoundingRadius
+	BoundingRadius float32
+
+	// NumMeshes
+	NumMeshes int32
+	// MeshIndex
+	MeshIndex int32
+
+	// NumVertices
+	NumVertices int32
+	// VertexInfoIndex
+	// One bone index byte per vertex
+	VertexInfoIndex int32
+	// VertexIndex
+	VertexIndex int32
+
+	// NumNormals
+	NumNormals int32
+	// NormalInfoIndex
+	// One bone index byte per normal
+	NormalInfoIndex int32
+	// NormalIndex
+	NormalIndex int32
+
+	// NumGroups
+	NumGroups int32
+	// GroupIndex
+	GroupIndex int32
+}
+
+// Mesh
+type Mesh struct {
+	// NumTriangles
+	NumTriangles int32
+	// TriangleIndex
+	// Offset of the triangle command list
+	TriangleIndex int32
+	// SkinReference
+	SkinReference int32
+	// NumNormals
+	NumNormals int32
+	// NormalIndex
+	NormalIndex int32
+}
+
+// Attachment
+type Attachment struct {
+	// Name
+	Name [32]byte
+	// Type
+	Type int32
+	// Bone
+	Bone int32
+	// Origin
+	Origin mgl32.Vec3
+	// Vectors
+	Vectors [3]mgl32.Vec3
+}
+
+// TriangleVertex is a single vertex reference inside a triangle command
+type TriangleVertex struct {
+	// VertexIndex
+	VertexIndex int16
+	// NormalIndex
+	NormalIndex int16
+	// S
+	// Horizontal texel coordinate
+	S int16
+	// T
+	// Vertical texel coordinate
+	T int16
+}
+
+// TriangleCommand is either a triangle strip or a triangle fan
+type TriangleCommand struct {
+	// Fan
+	Fan bool
+	// Vertices
+	Vertices []TriangleVertex
+}
+
+// TextureData contains a parsed texture with its embedded pixels
+type TextureData struct {
+	Header Texture
+	// Pixels
+	// Width*Height palette indices
+	Pixels []byte
+	// Palette
+	// 256 RGB triplets
+	Palette [256][3]byte
+}
+
+// MeshData contains a parsed mesh with its triangle commands
+type MeshData struct {
+	Header   Mesh
+	Commands []TriangleCommand
+}
+
+// ModelData contains a parsed model with its vertex data and meshes
+type ModelData struct {
+	Header Model
+	// Vertices
+	// Positions are relative to the bone referenced by VertexBones
+	Vertices []mgl32.Vec3
+	// VertexBones
+	VertexBones []uint8
+	// Normals
+	Normals []mgl32.Vec3
+	// NormalBones
+	NormalBones []uint8
+	// Meshes
+	Meshes []MeshData
+}
+
+// BodyPartData contains a parsed body part with its models
+type BodyPartData struct {
+	Header BodyPart
+	Models []ModelData
+}
+
+// Mdl represents the complete parsed data in a GoldSrc (version 10) mdl file.
+type Mdl struct {
+	// Header
+	Header Header
+	// Bones
+	Bones []Bone
+	// BoneControllers
+	BoneControllers []BoneController
+	// Hitboxes
+	Hitboxes []Hitbox
+	// Sequences
+	Sequences []SequenceDesc
+	// SequenceGroups
+	SequenceGroups []SequenceGroup
+	// Textures
+	Textures []TextureData
+	// Skins
+	// Skin families; each maps a skin reference to a texture index
+	Skins [][]int16
+	// BodyParts
+	BodyParts []BodyPartData
+	// Attachments
+	Attachments []Attachment
+
+	// animationData holds raw sequence group data. Index 0 is this file.
+	animationData [][]byte
+}
+
+// SequenceGroupData is a parsed external sequence group file
+type SequenceGroupData struct {
+	// Header
+	Header SequenceHeader
+	// Data
+	Data []byte
+}
+
+// Name returns the model name stored in the header
+func (mdl *Mdl) Name() string {
+	return cString(mdl.Header.Name[:])
+}
+
+// AttachSequenceGroup makes animation data from an external sequence group file available
+// to sequences that reference the given group index.
+func (mdl *Mdl) AttachSequenceGroup(index int, group *SequenceGroupData) {
+	for len(mdl.animationData) <= index {
+		mdl.animationData = append(mdl.animationData, nil)
+	}
+	mdl.animationData[index] = group.Data
+}
+
+// AttachTextures copies textures and skins from a separate texture file (e.g. fooT.mdl).
+// Models compiled with $externaltextures store no textures of their own.
+func (mdl *Mdl) AttachTextures(textureFile *Mdl) {
+	mdl.Textures = textureFile.Textures
+	mdl.Skins = textureFile.Skins
+}
+
+// cString returns the contents of a null-padded byte array
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,15 @@
+package goldsrc
+
+import "io"
+
+// ReadFromStream parses a GoldSrc mdl from an io.Reader stream
+func ReadFromStream(stream io.Reader) (*Mdl, error) {
+	reader := NewReader()
+	return reader.Read(stream)
+}
+
+// ReadSequenceGroupFromStream parses an external sequence group (*01.mdl) from an io.Reader stream
+func ReadSequenceGroupFromStream(stream io.Reader) (*SequenceGroupData, error) {
+	reader := NewReader()
+	return reader.ReadSequenceGroup(stream)
+}
@@ -0,0 +1,369 @@
+package goldsrc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// MDLMagicNumber is the expected file ID ("IDST" in little-endian)
+	MDLMagicNumber = 0x54534449
+	// SequenceGroupMagicNumber is the expected file ID of external sequence groups ("IDSQ" in little-endian)
+	SequenceGroupMagicNumber = 0x51534449
+	// MDLVersion is the only supported GoldSrc MDL version
+	MDLVersion = 10
+	// paletteSize is the size in bytes of the RGB palette following each texture
+	paletteSize = 256 * 3
+)
+
+// Reader is a parser for GoldSrc mdl files
+type Reader struct {
+}
+
+// Read parses the passed stream and returns an Mdl
+func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
+	byteBuf := bytes.Buffer{}
+	_, err := byteBuf.ReadFrom(stream)
+	if err != nil {
+		return nil, err
+	}
+	buf := byteBuf.Bytes()
+
+	header := Header{}
+	if err := readStructs(buf, 0, &header, "header"); err != nil {
+		return nil, fmt.Errorf("failed to read MDL header: %w", err)
+	}
+
+	// Validate magic number
+	if header.Id != MDLMagicNumber {
+		return nil, fmt.Errorf("invalid MDL magic number: got 0x%08X, expected 0x%08X", header.Id, MDLMagicNumber)
+	}
+
+	// Validate version
+	if header.Version != MDLVersion {
+		return nil, fmt.Errorf("unsupported GoldSrc MDL version: got %d, expected %d", header.Version, MDLVersion)
+	}
+
+	// Validate counts are non-negative
+	if header.BoneCount < 0 || header.BoneControllerCount < 0 || header.HitboxCount < 0 ||
+		header.SequenceCount < 0 || header.SequenceGroupCount < 0 || header.TextureCount < 0 ||
+		header.SkinReferenceCount < 0 || header.SkinFamilyCount < 0 || header.BodyPartCount < 0 ||
+		header.AttachmentCount < 0 {
+		return nil, fmt.Errorf("MDL header contains negative counts")
+	}
+
+	// Check every table fits in the file before allocating it
+	for _, table := range []struct {
+		count int32
+		data  interface{}
+		name  string
+	}{
+		{header.BoneCount, Bone{}, "bones"},
+		{header.BoneControllerCount, BoneController{}, "bone controllers"},
+		{header.HitboxCount, Hitbox{}, "hitboxes"},
+		{header.SequenceCount, SequenceDesc{}, "sequences"},
+		{header.SequenceGroupCount, SequenceGroup{}, "sequence groups"},
+		{header.AttachmentCount, Attachment{}, "attachments"},
+		{header.TextureCount, Texture{}, "textures"},
+		{header.BodyPartCount, BodyPart{}, "body parts"},
+	} {
+		if err := validateCount(buf, int64(table.count), binary.Size(table.data), table.name); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateCount(buf, int64(header.SkinFamilyCount)*int64(header.SkinReferenceCount), 2, "skins"); err != nil {
+		return nil, err
+	}
+
+	out := &Mdl{
+		Header:          header,
+		Bones:           make([]Bone, header.BoneCount),
+		BoneControllers: make([]BoneController, header.BoneControllerCount),
+		Hitboxes:        make([]Hitbox, header.HitboxCount),
+		Sequences:       make([]SequenceDesc, header.SequenceCount),
+		SequenceGroups:  make([]SequenceGroup, header.SequenceGroupCount),
+		Attachments:     make([]Attachment, header.AttachmentCount),
+		animationData:   [][]byte{buf},
+	}
+
+	if err := readStructs(buf, header.BoneOffset, &out.Bones, "bones"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, header.BoneControllerOffset, &out.BoneControllers, "bone controllers"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, header.HitboxOffset, &out.Hitboxes, "hitboxes"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, header.SequenceOffset, &out.Sequences, "sequences"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, header.SequenceGroupOffset, &out.SequenceGroups, "sequence groups"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, header.AttachmentOffset, &out.Attachments, "attachments"); err != nil {
+		return nil, err
+	}
+
+	if out.Textures, err = reader.readTextures(buf, &header); err != nil {
+		return nil, err
+	}
+
+	if out.Skins, err = reader.readSkins(buf, &header); err != nil {
+		return nil, err
+	}
+
+	if out.BodyParts, err = reader.readBodyParts(buf, &header); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ReadSequenceGroup parses an external sequence group file (e.g. scientist01.mdl)
+func (reader *Reader) ReadSequenceGroup(stream io.Reader) (*SequenceGroupData, error) {
+	byteBuf := bytes.Buffer{}
+	_, err := byteBuf.ReadFrom(stream)
+	if err != nil {
+		return nil, err
+	}
+	buf := byteBuf.Bytes()
+
+	header := SequenceHeader{}
+	if err := readStructs(buf, 0, &header, "sequence group header"); err != nil {
+		return nil, err
+	}
+
+	if header.Id != SequenceGroupMagicNumber {
+		return nil, fmt.Errorf("invalid sequence group magic number: got 0x%08X, expected 0x%08X", header.Id, SequenceGroupMagicNumber)
+	}
+	if header.Version != MDLVersion {
+		return nil, fmt.Errorf("unsupported sequence group version: got %d, expected %d", header.Version, MDLVersion)
+	}
+
+	return &SequenceGroupData{
+		Header: header,
+		Data:   buf,
+	}, nil
+}
+
+// readTextures reads texture headers and their embedded pixel data and palettes
+func (reader *Reader) readTextures(buf []byte, header *Header) ([]TextureData, error) {
+	headers := make([]Texture, header.TextureCount)
+	if err := readStructs(buf, header.TextureOffset, &headers, "textures"); err != nil {
+		return nil, err
+	}
+
+	textures := make([]TextureData, len(headers))
+	for i, texture := range headers {
+		if texture.Width < 0 || texture.Height < 0 {
+			return nil, fmt.Errorf("texture %d has invalid dimensions %dx%d", i, texture.Width, texture.Height)
+		}
+		if err := validateCount(buf, int64(texture.Width)*int64(texture.Height)+paletteSize, 1, "texture data"); err != nil {
+			return nil, fmt.Errorf("texture %d: %w", i, err)
+		}
+		numPixels := texture.Width * texture.Height
+		if err := validateOffset(buf, texture.Index, numPixels+paletteSize, "texture data"); err != nil {
+			return nil, fmt.Errorf("texture %d: %w", i, err)
+		}
+
+		textures[i].Header = texture
+		textures[i].Pixels = buf[texture.Index : texture.Index+numPixels]
+		palette := buf[texture.Index+numPixels : texture.Index+numPixels+paletteSize]
+		for j := range textures[i].Palette {
+			copy(textures[i].Palette[j][:], palette[j*3:j*3+3])
+		}
+	}
+
+	return textures, nil
+}
+
+// readSkins reads the skin family table
+func (reader *Reader) readSkins(buf []byte, header *Header) ([][]int16, error) {
+	skins := make([][]int16, header.SkinFamilyCount)
+	familySize := header.SkinReferenceCount * 2
+	for i := range skins {
+		skins[i] = make([]int16, header.SkinReferenceCount)
+		if err := readStructs(buf, header.SkinOffset+int32(i)*familySize, &skins[i], "skins"); err != nil {
+			return nil, err
+		}
+	}
+
+	return skins, nil
+}
+
+// readBodyParts parses the body part hierarchy (body parts → models → meshes)
+func (reader *Reader) readBodyParts(buf []byte, header *Header) ([]BodyPartData, error) {
+	headers := make([]BodyPart, header.BodyPartCount)
+	if err := readStructs(buf, header.BodyPartOffset, &headers, "body parts"); err != nil {
+		return nil, err
+	}
+
+	bodyParts := make([]BodyPartData, len(headers))
+	for i, bodyPart := range headers {
+		if err := validateCount(buf, int64(bodyPart.NumModels), binary.Size(Model{}), "models"); err != nil {
+			return nil, fmt.Errorf("body part %d: %w", i, err)
+		}
+		models := make([]Model, bodyPart.NumModels)
+		if err := readStructs(buf, bodyPart.ModelIndex, &models, "models"); err != nil {
+			return nil, fmt.Errorf("body part %d: %w", i, err)
+		}
+
+		bodyParts[i] = BodyPartData{
+			Header: bodyPart,
+			Models: make([]ModelData, len(models)),
+		}
+		for j, model := range models {
+			modelData, err := reader.readModel(buf, model)
+			if err != nil {
+				return nil, fmt.Errorf("model %d in body part %d: %w", j, i, err)
+			}
+			bodyParts[i].Models[j] = *modelData
+		}
+	}
+
+	return bodyParts, nil
+}
+
+// readModel reads vertex data and meshes of a single model.
+// All offsets in a GoldSrc model are absolute.
+func (reader *Reader) readModel(buf []byte, model Model) (*ModelData, error) {
+	if err := validateCount(buf, int64(model.NumVertices), binary.Size(mgl32.Vec3{}), "vertices"); err != nil {
+		return nil, err
+	}
+	if err := validateCount(buf, int64(model.NumNormals), binary.Size(mgl32.Vec3{}), "normals"); err != nil {
+		return nil, err
+	}
+	if err := validateCount(buf, int64(model.NumMeshes), binary.Size(Mesh{}), "meshes"); err != nil {
+		return nil, err
+	}
+
+	out := &ModelData{
+		Header:      model,
+		Vertices:    make([]mgl32.Vec3, model.NumVertices),
+		VertexBones: make([]uint8, model.NumVertices),
+		Normals:     make([]mgl32.Vec3, model.NumNormals),
+		NormalBones: make([]uint8, model.NumNormals),
+	}
+
+	if err := readStructs(buf, model.VertexIndex, &out.Vertices, "vertices"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, model.VertexInfoIndex, &out.VertexBones, "vertex bones"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, model.NormalIndex, &out.Normals, "normals"); err != nil {
+		return nil, err
+	}
+	if err := readStructs(buf, model.NormalInfoIndex, &out.NormalBones, "normal bones"); err != nil {
+		return nil, err
+	}
+
+	meshes := make([]Mesh, model.NumMeshes)
+	if err := readStructs(buf, model.MeshIndex, &meshes, "meshes"); err != nil {
+		return nil, err
+	}
+
+	out.Meshes = make([]MeshData, len(meshes))
+	for i, mesh := range meshes {
+		commands, err := reader.readTriangleCommands(buf, mesh.TriangleIndex)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d: %w", i, err)
+		}
+		out.Meshes[i] = MeshData{
+			Header:   mesh,
+			Commands: commands,
+		}
+	}
+
+	return out, nil
+}
+
+// readTriangleCommands reads a zero terminated list of strips and fans.
+// A positive count is a strip, a negative count a fan.
+func (reader *Reader) readTriangleCommands(buf []byte, offset int32) ([]TriangleCommand, error) {
+	commands := make([]TriangleCommand, 0)
+	vertexSize := int32(binary.Size(TriangleVertex{}))
+
+	for {
+		var count int16
+		if err := readStructs(buf, offset, &count, "triangle command"); err != nil {
+			return nil, err
+		}
+		offset += 2
+		if count == 0 {
+			break
+		}
+
+		// Widen before negating, -32768 has no positive int16
+		numVertices := int(count)
+		command := TriangleCommand{}
+		if numVertices < 0 {
+			command.Fan = true
+			numVertices = -numVertices
+		}
+		if err := validateOffset(buf, offset, int32(numVertices)*vertexSize, "triangle command vertices"); err != nil {
+			return nil, err
+		}
+		command.Vertices = make([]TriangleVertex, numVertices)
+		if err := readStructs(buf, offset, &command.Vertices, "triangle command vertices"); err != nil {
+			return nil, err
+		}
+		offset += int32(numVertices) * vertexSize
+
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+// NewReader returns a new Reader.
+func NewReader() *Reader {
+	return new(Reader)
+}
+
+// readStructs decodes data at offset into out, checking bounds first
+func readStructs(buf []byte, offset int32, out interface{}, name string) error {
+	size := int32(binary.Size(out))
+	if size == 0 {
+		return nil
+	}
+	if err := validateOffset(buf, offset, size, name); err != nil {
+		return err
+	}
+	if err := binary.Read(bytes.NewBuffer(buf[offset:offset+size]), binary.LittleEndian, out); err != nil {
+		return fmt.Errorf("failed to read %s at offset %d: %w", name, offset, err)
+	}
+	return nil
+}
+
+// validateCount checks a count is non-negative and that count elements of size bytes
+// could fit in the buffer, so untrusted counts never drive large allocations
+func validateCount(buf []byte, count int64, size int, name string) error {
+	if count < 0 {
+		return fmt.Errorf("%s count is negative: %d", name, count)
+	}
+	if count*int64(size) > int64(len(buf)) {
+		return fmt.Errorf("%s count %d exceeds buffer: size=%d bufferSize=%d", name, count, count*int64(size), len(buf))
+	}
+	return nil
+}
+
+// validateOffset checks if the given offset and size are within buffer bounds
+func validateOffset(buf []byte, offset int32, size int32, name string) error {
+	if offset < 0 {
+		return fmt.Errorf("%s offset is negative: %d", name, offset)
+	}
+	if size < 0 {
+		return fmt.Errorf("%s size is negative: %d", name, size)
+	}
+	requiredSize := int(offset) + int(size)
+	if requiredSize > len(buf) {
+		return fmt.Errorf("%s data exceeds buffer: offset=%d size=%d required=%d bufferSize=%d", name, offset, size, requiredSize, len(buf))
+	}
+	return nil
+}
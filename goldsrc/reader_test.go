@@ -0,0 +1,175 @@
+package goldsrc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// mdlFixture is a minimal GoldSrc model: one bone, one 2x2 texture, one skin family and
+// a single body part whose model is one triangle strip
+type mdlFixture struct {
+	header   Header
+	texture  Texture
+	bodyPart BodyPart
+	model    Model
+	mesh     Mesh
+	// commandCount is the vertex count of the mesh's only triangle command
+	commandCount int16
+}
+
+// buildMdl lays out the fixture, lets mutate alter any header before it is written and
+// returns the encoded file
+func buildMdl(t *testing.T, mutate func(*mdlFixture)) []byte {
+	t.Helper()
+
+	vertices := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}}
+	normals := []mgl32.Vec3{{0, 0, 1}}
+	commands := []TriangleVertex{
+		{VertexIndex: 0, S: 0, T: 0},
+		{VertexIndex: 1, S: 2, T: 0},
+		{VertexIndex: 2, S: 0, T: 2},
+		{VertexIndex: 3, S: 2, T: 2},
+	}
+
+	fixture := &mdlFixture{}
+	offset := int32(binary.Size(Header{}))
+	next := func(data interface{}) int32 {
+		pos := offset
+		offset += int32(binary.Size(data))
+		return pos
+	}
+
+	fixture.header = Header{Id: MDLMagicNumber, Version: MDLVersion, BoneCount: 1, TextureCount: 1,
+		SkinReferenceCount: 1, SkinFamilyCount: 1, BodyPartCount: 1}
+	fixture.header.BoneOffset = next(Bone{})
+	fixture.header.TextureOffset = next(Texture{})
+	fixture.texture = Texture{Width: 2, Height: 2, Index: next(make([]byte, 4+paletteSize))}
+	fixture.header.SkinOffset = next(int16(0))
+	fixture.header.BodyPartOffset = next(BodyPart{})
+	fixture.bodyPart = BodyPart{NumModels: 1, ModelIndex: next(Model{})}
+	fixture.model = Model{
+		NumMeshes:       1,
+		NumVertices:     int32(len(vertices)),
+		VertexIndex:     next(vertices),
+		VertexInfoIndex: next(make([]uint8, len(vertices))),
+		NumNormals:      int32(len(normals)),
+		NormalIndex:     next(normals),
+		NormalInfoIndex: next(make([]uint8, len(normals))),
+		MeshIndex:       next(Mesh{}),
+	}
+	fixture.mesh = Mesh{NumTriangles: 2, TriangleIndex: offset}
+	fixture.commandCount = int16(len(commands))
+
+	if mutate != nil {
+		mutate(fixture)
+	}
+
+	buf := &bytes.Buffer{}
+	write := func(data interface{}) {
+		if err := binary.Write(buf, binary.LittleEndian, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bone := Bone{Parent: -1}
+	copy(bone.Name[:], "root")
+	write(fixture.header)
+	write(bone)
+	write(fixture.texture)
+	write([]byte{1, 2, 3, 4})
+	write(make([]byte, paletteSize))
+	write(int16(0))
+	write(fixture.bodyPart)
+	write(fixture.model)
+	write(vertices)
+	write(make([]uint8, len(vertices)))
+	write(normals)
+	write(make([]uint8, len(normals)))
+	write(fixture.mesh)
+	write(fixture.commandCount)
+	write(commands)
+	write(int16(0))
+
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	mdl, err := ReadFromStream(bytes.NewReader(buildMdl(t, nil)))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	if len(mdl.Bones) != 1 || cString(mdl.Bones[0].Name[:]) != "root" {
+		t.Errorf("bones = %+v", mdl.Bones)
+	}
+	if len(mdl.Textures) != 1 || !bytes.Equal(mdl.Textures[0].Pixels, []byte{1, 2, 3, 4}) {
+		t.Errorf("textures = %+v", mdl.Textures)
+	}
+	if len(mdl.Skins) != 1 || len(mdl.Skins[0]) != 1 {
+		t.Errorf("skins = %v", mdl.Skins)
+	}
+
+	model := mdl.BodyParts[0].Models[0]
+	if len(model.Vertices) != 4 || len(model.Normals) != 1 || len(model.Meshes) != 1 {
+		t.Fatalf("model has %d vertices, %d normals, %d meshes", len(model.Vertices), len(model.Normals), len(model.Meshes))
+	}
+	commands := model.Meshes[0].Commands
+	if len(commands) != 1 || commands[0].Fan || len(commands[0].Vertices) != 4 {
+		t.Fatalf("commands = %+v", commands)
+	}
+
+	meshes, err := mdl.Meshes(0, 0, 0)
+	if err != nil {
+		t.Fatalf("meshes failed: %v", err)
+	}
+	want := []uint32{0, 1, 2, 2, 1, 3}
+	if len(meshes) != 1 || len(meshes[0].Indices) != len(want) {
+		t.Fatalf("meshes = %+v", meshes)
+	}
+	for i := range want {
+		if meshes[0].Indices[i] != want[i] {
+			t.Fatalf("strip indices = %v, want %v", meshes[0].Indices, want)
+		}
+	}
+	if uv := meshes[0].UVs[3]; uv != (mgl32.Vec2{1, 1}) {
+		t.Errorf("uv of last vertex = %v, want [1 1]", uv)
+	}
+}
+
+func TestReadRejectsCorruptCounts(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(*mdlFixture)
+		want   string
+	}{
+		{"bad magic", func(f *mdlFixture) { f.header.Id = 0 }, "magic"},
+		{"bad version", func(f *mdlFixture) { f.header.Version = 44 }, "version"},
+		{"negative header count", func(f *mdlFixture) { f.header.BoneCount = -1 }, "negative"},
+		{"huge header count", func(f *mdlFixture) { f.header.SequenceCount = 1 << 30 }, "sequences count"},
+		{"huge skin table", func(f *mdlFixture) { f.header.SkinFamilyCount, f.header.SkinReferenceCount = 1<<20, 1<<20 }, "skins count"},
+		{"negative models", func(f *mdlFixture) { f.bodyPart.NumModels = -1 }, "models count is negative"},
+		{"huge models", func(f *mdlFixture) { f.bodyPart.NumModels = 1 << 30 }, "models count"},
+		{"negative vertices", func(f *mdlFixture) { f.model.NumVertices = -4 }, "vertices count is negative"},
+		{"huge normals", func(f *mdlFixture) { f.model.NumNormals = 1 << 30 }, "normals count"},
+		{"negative meshes", func(f *mdlFixture) { f.model.NumMeshes = -1 }, "meshes count is negative"},
+		{"overflowing texture", func(f *mdlFixture) { f.texture.Width, f.texture.Height = 1<<16, 1<<16 }, "texture data count"},
+		{"texture past end", func(f *mdlFixture) { f.texture.Index = 1 << 20 }, "exceeds buffer"},
+		{"strip past end", func(f *mdlFixture) { f.commandCount = 0x7fff }, "triangle command vertices"},
+		{"largest fan", func(f *mdlFixture) { f.commandCount = -0x8000 }, "triangle command vertices"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadFromStream(bytes.NewReader(buildMdl(t, tc.mutate)))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q does not mention %q", err, tc.want)
+			}
+		})
+	}
+}
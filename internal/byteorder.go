@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DetectByteOrder returns the byte order in which the leading int32 of buf equals expected.
+// PC files are little-endian; Xbox 360 and PS3 (.360.) files are byte swapped.
+func DetectByteOrder(buf []byte, expected int32) (binary.ByteOrder, error) {
+	if len(buf) < 4 {
+		return nil, fmt.Errorf("buffer too small to detect byte order: %d bytes", len(buf))
+	}
+	if int32(binary.LittleEndian.Uint32(buf)) == expected {
+		return binary.LittleEndian, nil
+	}
+	if int32(binary.BigEndian.Uint32(buf)) == expected {
+		return binary.BigEndian, nil
+	}
+	return nil, fmt.Errorf("unrecognised identifier 0x%08X, expected 0x%08X in either byte order", binary.LittleEndian.Uint32(buf), uint32(expected))
+}
@@ -0,0 +1,323 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KeyValue is a node of a Valve KeyValues document.
+// Blocks have Children, everything else has a Value. Keys may repeat.
+type KeyValue struct {
+	// Key
+	Key string
+	// Value
+	Value string
+	// Children
+	Children []*KeyValue
+	// IsBlock
+	IsBlock bool
+}
+
+// Find returns the first child with a key, compared case-insensitively
+func (kv *KeyValue) Find(key string) *KeyValue {
+	for _, child := range kv.Children {
+		if strings.EqualFold(child.Key, key) {
+			return child
+		}
+	}
+	return nil
+}
+
+// FindAll returns every child with a key, compared case-insensitively
+func (kv *KeyValue) FindAll(key string) []*KeyValue {
+	ret := make([]*KeyValue, 0)
+	for _, child := range kv.Children {
+		if strings.EqualFold(child.Key, key) {
+			ret = append(ret, child)
+		}
+	}
+	return ret
+}
+
+// String returns the value of the first child with a key
+func (kv *KeyValue) String(key string) (string, bool) {
+	child := kv.Find(key)
+	if child == nil || child.IsBlock {
+		return "", false
+	}
+	return child.Value, true
+}
+
+// Float returns the value of the first child with a key as a float.
+// Missing or malformed values return fallback.
+func (kv *KeyValue) Float(key string, fallback float32) float32 {
+	value, ok := kv.String(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+	if err != nil {
+		return fallback
+	}
+	return float32(f)
+}
+
+// Int returns the value of the first child with a key as an int.
+// Missing or malformed values return fallback.
+func (kv *KeyValue) Int(key string, fallback int) int {
+	value, ok := kv.String(key)
+	if !ok {
+		return fallback
+	}
+	value = strings.TrimSpace(value)
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		// Valve tools happily write integers as floats
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fallback
+		}
+		return int(f)
+	}
+	return i
+}
+
+// Bool returns the value of the first child with a key as a bool. Any non-zero number is true.
+// Missing or malformed values return fallback.
+func (kv *KeyValue) Bool(key string, fallback bool) bool {
+	if _, ok := kv.String(key); !ok {
+		return fallback
+	}
+	return kv.Float(key, 0) != 0
+}
+
+// Values returns all leaf children as a map. Later duplicate keys win.
+// Keys are lowercased.
+func (kv *KeyValue) Values() map[string]string {
+	values := make(map[string]string)
+	for _, child := range kv.Children {
+		if !child.IsBlock {
+			values[strings.ToLower(child.Key)] = child.Value
+		}
+	}
+	return values
+}
+
+// ParseKeyValues parses a KeyValues document into its top level nodes.
+// Supports quoted and unquoted tokens, // comments and [$PLATFORM] conditionals (which are
+// ignored, keeping the entry). Quoted tokens resolve \n, \t, \\ and \" escapes.
+func ParseKeyValues(text string) ([]*KeyValue, error) {
+	return parseKeyValues(text, true)
+}
+
+// ParseKeyValuesRaw parses a KeyValues document like ParseKeyValues, but without escape
+// sequences: a quoted token ends at the next quote and backslashes are kept as written.
+// This matches the engine's vcollide parser, which reads PHY text.
+func ParseKeyValuesRaw(text string) ([]*KeyValue, error) {
+	return parseKeyValues(text, false)
+}
+
+// parseKeyValues parses a KeyValues document, optionally resolving escape sequences
+func parseKeyValues(text string, escapes bool) ([]*KeyValue, error) {
+	tokenizer := &kvTokenizer{text: text, escapes: escapes}
+	nodes, err := tokenizer.parseBlock(false)
+	if err != nil {
+		return nil, fmt.Errorf("keyvalues: line %d: %w", tokenizer.line+1, err)
+	}
+	return nodes, nil
+}
+
+// WriteKeyValues writes nodes as a KeyValues document, escaping quotes and backslashes
+func WriteKeyValues(w io.Writer, nodes []*KeyValue) error {
+	return writeKeyValues(w, nodes, 0, true)
+}
+
+// WriteKeyValuesRaw writes nodes as a KeyValues document without escape sequences, to be
+// read by ParseKeyValuesRaw or the engine's vcollide parser.
+// Tokens containing a quote cannot be represented and return an error.
+func WriteKeyValuesRaw(w io.Writer, nodes []*KeyValue) error {
+	return writeKeyValues(w, nodes, 0, false)
+}
+
+// writeKeyValues writes nodes at a nesting depth
+func writeKeyValues(w io.Writer, nodes []*KeyValue, depth int, escapes bool) error {
+	indent := strings.Repeat("\t", depth)
+	for _, node := range nodes {
+		key, err := quoteKeyValue(node.Key, escapes)
+		if err != nil {
+			return err
+		}
+		if node.IsBlock {
+			if _, err = fmt.Fprintf(w, "%s%s\n%s{\n", indent, key, indent); err != nil {
+				return err
+			}
+			if err = writeKeyValues(w, node.Children, depth+1, escapes); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "%s}\n", indent)
+		} else {
+			var value string
+			if value, err = quoteKeyValue(node.Value, escapes); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "%s%s %s\n", indent, key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteKeyValue quotes a token. With escapes, quotes and backslashes are escaped, otherwise
+// the token is written as is and must not contain a quote.
+func quoteKeyValue(s string, escapes bool) (string, error) {
+	if !escapes {
+		if strings.ContainsRune(s, '"') {
+			return "", fmt.Errorf("keyvalues: %q contains a quote, which cannot be written without escapes", s)
+		}
+		return `"` + s + `"`, nil
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`, nil
+}
+
+// kvTokenType
+type kvTokenType int
+
+const (
+	kvTokenEOF kvTokenType = iota
+	kvTokenString
+	kvTokenOpen
+	kvTokenClose
+	kvTokenConditional
+)
+
+// kvTokenizer
+type kvTokenizer struct {
+	text    string
+	pos     int
+	line    int
+	escapes bool
+}
+
+// parseBlock parses nodes until the end of the document, or a closing brace if nested
+func (t *kvTokenizer) parseBlock(nested bool) ([]*KeyValue, error) {
+	nodes := make([]*KeyValue, 0)
+	for {
+		tokenType, key := t.next()
+		switch tokenType {
+		case kvTokenEOF:
+			if nested {
+				return nil, fmt.Errorf("unexpected end of document, expected }")
+			}
+			return nodes, nil
+		case kvTokenClose:
+			if !nested {
+				return nil, fmt.Errorf("unexpected }")
+			}
+			return nodes, nil
+		case kvTokenOpen:
+			return nil, fmt.Errorf("unexpected {, expected key")
+		case kvTokenConditional:
+			continue
+		}
+
+		tokenType, value := t.next()
+		for tokenType == kvTokenConditional {
+			tokenType, value = t.next()
+		}
+		switch tokenType {
+		case kvTokenString:
+			nodes = append(nodes, &KeyValue{Key: key, Value: value})
+		case kvTokenOpen:
+			children, err := t.parseBlock(true)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, &KeyValue{Key: key, Children: children, IsBlock: true})
+		default:
+			return nil, fmt.Errorf("missing value for key %q", key)
+		}
+	}
+}
+
+// next returns the next token
+func (t *kvTokenizer) next() (kvTokenType, string) {
+	for t.pos < len(t.text) {
+		c := t.text[t.pos]
+		switch {
+		case c == '\n':
+			t.line++
+			t.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == 0:
+			t.pos++
+		case c == '/' && t.pos+1 < len(t.text) && t.text[t.pos+1] == '/':
+			for t.pos < len(t.text) && t.text[t.pos] != '\n' {
+				t.pos++
+			}
+		case c == '{':
+			t.pos++
+			return kvTokenOpen, "{"
+		case c == '}':
+			t.pos++
+			return kvTokenClose, "}"
+		case c == '"':
+			return kvTokenString, t.readQuoted()
+		case c == '[':
+			end := strings.IndexByte(t.text[t.pos:], ']')
+			if end < 0 {
+				end = len(t.text) - t.pos - 1
+			}
+			token := t.text[t.pos : t.pos+end+1]
+			t.pos += end + 1
+			return kvTokenConditional, token
+		default:
+			start := t.pos
+			for t.pos < len(t.text) && !strings.ContainsRune(" \t\r\n{}\"", rune(t.text[t.pos])) {
+				t.pos++
+			}
+			return kvTokenString, t.text[start:t.pos]
+		}
+	}
+	return kvTokenEOF, ""
+}
+
+// readQuoted reads a quoted string, resolving escape sequences if enabled.
+// An unterminated string runs to the end of the document.
+func (t *kvTokenizer) readQuoted() string {
+	t.pos++ // opening quote
+	sb := strings.Builder{}
+	for t.pos < len(t.text) {
+		c := t.text[t.pos]
+		t.pos++
+		switch {
+		case c == '"':
+			return sb.String()
+		case c == '\\' && t.escapes && t.pos < len(t.text):
+			escaped := t.text[t.pos]
+			t.pos++
+			switch escaped {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case '\\', '"':
+				sb.WriteByte(escaped)
+			default:
+				// Unknown escapes are kept verbatim, as paths use backslashes
+				sb.WriteByte('\\')
+				sb.WriteByte(escaped)
+			}
+		default:
+			if c == '\n' {
+				t.line++
+			}
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// dumpKeyValues flattens nodes to key=value lines, with blocks as key{...}
+func dumpKeyValues(nodes []*KeyValue) string {
+	sb := strings.Builder{}
+	for _, node := range nodes {
+		if node.IsBlock {
+			sb.WriteString(node.Key + "{" + dumpKeyValues(node.Children) + "}")
+		} else {
+			sb.WriteString(node.Key + "=" + node.Value + ";")
+		}
+	}
+	return sb.String()
+}
+
+func TestParseKeyValues(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		raw  bool
+		want string
+		// err is a substring of the expected error
+		err string
+	}{
+		{"quoted", `"solid" { "name" "pelvis" "mass" "10" }`, false, "solid{name=pelvis;mass=10;}", ""},
+		{"unquoted", "solid\n{\n\tindex 0\n\tname pelvis\n}", false, "solid{index=0;name=pelvis;}", ""},
+		{"unquoted runs into brace", `block{key value}`, false, "block{key=value;}", ""},
+		{"repeated keys", `"a" "1" "a" "2"`, false, "a=1;a=2;", ""},
+		{"comments", "// header\n\"a\" \"1\" // trailing\n// \"b\" \"2\"\n\"c\" \"http://x\"", false, "a=1;c=http://x;", ""},
+		{"platform conditionals", `"a" "1" [$X360] "b" [$WIN32] "2" [!$X360] "c" { "d" "3" } [$OSX]`, false, "a=1;b=2;c{d=3;}", ""},
+		{"escapes", `"a" "line\none\ttab \"quoted\" back\\slash"`, false, "a=line\none\ttab \"quoted\" back\\slash;", ""},
+		{"unknown escape kept", `"path" "models\props\crate"`, false, `path=models\props\crate;`, ""},
+		{"raw keeps backslashes", `"path" "C:\new\\dir\"`, true, `path=C:\new\\dir\;`, ""},
+		{"empty", "  \n// nothing\n", false, "", ""},
+		{"unterminated block", "\"a\"\n{\n\"b\" \"1\"\n", false, "", "line 4: unexpected end of document, expected }"},
+		{"unterminated string", "\"a\" \"1\"\n\"b\" \"2", false, "a=1;b=2;", ""},
+		{"unterminated key", "\"a\" \"1\"\n\"b", false, "", `line 2: missing value for key "b"`},
+		{"missing value", `"a" "1" "b" }`, false, "", `missing value for key "b"`},
+		{"stray close", `"a" "1" }`, false, "", "unexpected }"},
+		{"block without key", `{ "a" "1" }`, false, "", "unexpected {"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parse := ParseKeyValues
+			if tc.raw {
+				parse = ParseKeyValuesRaw
+			}
+			nodes, err := parse(tc.text)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if got := dumpKeyValues(nodes); got != tc.want {
+				t.Errorf("parsed %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKeyValueAccessors(t *testing.T) {
+	nodes, err := ParseKeyValues(`"root" { "Mass" "10.5" "count" "3.0" "bad" "x" "flag" "1" "Flag" "0" "child" { } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := nodes[0]
+
+	if root.Float("mass", 0) != 10.5 || root.Float("bad", 2) != 2 || root.Float("missing", 3) != 3 {
+		t.Error("Float did not parse or fall back")
+	}
+	if root.Int("count", 0) != 3 || root.Int("bad", 7) != 7 {
+		t.Error("Int did not parse a float value or fall back")
+	}
+	if !root.Bool("flag", false) || root.Bool("missing", false) {
+		t.Error("Bool should use the first matching key")
+	}
+	if _, ok := root.String("child"); ok {
+		t.Error("a block has no string value")
+	}
+	if len(root.FindAll("FLAG")) != 2 {
+		t.Error("FindAll should match case-insensitively")
+	}
+	if values := root.Values(); values["flag"] != "0" || values["mass"] != "10.5" || len(values) != 4 {
+		t.Errorf("values = %v", values)
+	}
+}
+
+func TestWriteKeyValuesRoundTrip(t *testing.T) {
+	nodes := []*KeyValue{
+		{Key: "solid", IsBlock: true, Children: []*KeyValue{
+			{Key: "name", Value: "pelvis"},
+			{Key: "path", Value: `models\props\new_crate`},
+			{Key: "empty", Value: ""},
+			{Key: "nested", IsBlock: true, Children: []*KeyValue{{Key: "key with spaces", Value: "{ braces }"}}},
+		}},
+		{Key: "quoted", Value: `say "hi"` + "\n"},
+	}
+
+	cases := []struct {
+		name  string
+		write func(w *bytes.Buffer, nodes []*KeyValue) error
+		parse func(string) ([]*KeyValue, error)
+		nodes []*KeyValue
+		// text is a substring of the written document
+		text string
+	}{
+		{"escaped", func(w *bytes.Buffer, nodes []*KeyValue) error { return WriteKeyValues(w, nodes) },
+			ParseKeyValues, nodes, `"path" "models\\props\\new_crate"`},
+		{"raw", func(w *bytes.Buffer, nodes []*KeyValue) error { return WriteKeyValuesRaw(w, nodes) },
+			ParseKeyValuesRaw, nodes[:1], `"path" "models\props\new_crate"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := tc.write(buf, tc.nodes); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if !strings.Contains(buf.String(), tc.text) {
+				t.Errorf("written document does not contain %s:\n%s", tc.text, buf)
+			}
+			parsed, err := tc.parse(buf.String())
+			if err != nil {
+				t.Fatalf("parse failed: %v\n%s", err, buf)
+			}
+			if got, want := dumpKeyValues(parsed), dumpKeyValues(tc.nodes); got != want {
+				t.Errorf("round trip = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if err := WriteKeyValuesRaw(&bytes.Buffer{}, nodes); err == nil {
+		t.Error("expected an error writing a quote without escapes")
+	}
+}
@@ -0,0 +1,117 @@
+package studiomodel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/galaco/studiomodel/mdl"
+	"github.com/galaco/studiomodel/phy"
+	"github.com/galaco/studiomodel/vtx"
+	"github.com/galaco/studiomodel/vvd"
+)
+
+// ErrChecksumMismatch is returned when a vvd, vtx or phy does not belong to the mdl it
+// was loaded with. It is vtx.ErrChecksumMismatch, so either can be used with errors.Is
+var ErrChecksumMismatch = vtx.ErrChecksumMismatch
+
+// Loader reads every file of a model from a filesystem
+type Loader struct {
+	// VtxPreference lists vtx variants in the order they are tried.
+	// vtx.DefaultVariantPreference is used if empty
+	VtxPreference []vtx.Variant
+}
+
+// NewLoader returns a new Loader
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// Load reads a model from fsys. modelPath is the model path, with or without its .mdl extension.
+// The mdl, vvd and a vtx are required; a missing phy or ani is recorded in StudioModel.Missing.
+// The phy is optional, so one that fails to read or match is reported in StudioModel.PhyErr
+// rather than failing the load.
+// The ani is located by the mdl's AnimBlockName, which is relative to the game directory, so
+// fsys should be rooted there for it to be found.
+func (loader *Loader) Load(fsys fs.FS, modelPath string) (*StudioModel, error) {
+	basePath := strings.TrimSuffix(modelPath, ".mdl")
+	model := NewStudioModel(basePath + ".mdl")
+
+	// MDL
+	mdlFile, err := readFile(fsys, basePath+".mdl", mdl.ReadFromStream)
+	if err != nil {
+		return nil, err
+	}
+	model.AddMdl(mdlFile)
+	checksum := mdlFile.Header.Checksum
+
+	// VVD
+	vvdFile, err := readFile(fsys, basePath+".vvd", vvd.ReadFromStream)
+	if err != nil {
+		return nil, err
+	}
+	if vvdFile.Header.Checksum != checksum {
+		return nil, fmt.Errorf("%s.vvd: %w (%d, mdl has %d)", basePath, ErrChecksumMismatch, vvdFile.Header.Checksum, checksum)
+	}
+	model.AddVvd(vvdFile)
+
+	// VTX
+	vtxFile, err := vtx.NewLoader(loader.VtxPreference...).Load(fsys, basePath, mdlFile.Header.Version, checksum)
+	if err != nil {
+		return nil, err
+	}
+	model.AddVtx(vtxFile)
+
+	// PHY
+	phyFile, err := readFile(fsys, basePath+".phy", phy.ReadFromStream)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		model.Missing = append(model.Missing, basePath+".phy")
+	case err != nil:
+		model.PhyErr = err
+	case phyFile.Header.CheckSum != checksum:
+		model.PhyErr = fmt.Errorf("%s.phy: %w (%d, mdl has %d)", basePath, ErrChecksumMismatch, phyFile.Header.CheckSum, checksum)
+	default:
+		model.AddPhy(phyFile)
+	}
+
+	// ANI
+	// Only models built with $animblocksize have one, and the mdl names it
+	if mdlFile.AnimBlockName != "" {
+		aniPath := path.Clean(strings.ReplaceAll(mdlFile.AnimBlockName, "\\", "/"))
+		ani, err := fs.ReadFile(fsys, aniPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			model.Missing = append(model.Missing, aniPath)
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", aniPath, err)
+		default:
+			model.Ani = ani
+		}
+	}
+
+	return model, nil
+}
+
+// Load reads a model and its related files from fsys, see Loader.Load
+func Load(fsys fs.FS, modelPath string) (*StudioModel, error) {
+	return NewLoader().Load(fsys, modelPath)
+}
+
+// readFile opens a file and parses it
+func readFile[T any](fsys fs.FS, filePath string, read func(io.Reader) (*T, error)) (*T, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	out, err := read(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return out, nil
+}
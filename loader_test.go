@@ -0,0 +1,164 @@
+package studiomodel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/galaco/studiomodel/mdl"
+	"github.com/galaco/studiomodel/phy"
+	"github.com/galaco/studiomodel/vvd"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// encode writes values in order as a little endian file
+func encode(t *testing.T, values ...any) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	for _, value := range values {
+		if err := binary.Write(buf, binary.LittleEndian, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// encodeMdl returns an empty version 48 mdl. A non-empty animBlockName is stored after the header
+func encodeMdl(t *testing.T, checksum int32, animBlockName string) []byte {
+	header := mdl.Studiohdr{Id: mdl.MDLMagicNumber, Version: 48, Checksum: checksum}
+	if animBlockName == "" {
+		return encode(t, header)
+	}
+	header.AnimblocksCount = 1
+	header.AnimblocksNameIndex = int32(binary.Size(header))
+	return encode(t, header, []byte(animBlockName+"\x00"))
+}
+
+// encodeVvd returns a single LOD vvd without vertices
+func encodeVvd(t *testing.T, checksum int32) []byte {
+	size := int32(binary.Size(vvd.Header{}))
+	return encode(t, vvd.Header{
+		Id:               vvd.VVDMagicNumber,
+		Version:          vvd.VVDVersion,
+		Checksum:         checksum,
+		NumLODs:          1,
+		FixupTableStart:  size,
+		VertexDataStart:  size,
+		TangentDataStart: size,
+	})
+}
+
+// encodeVtx returns a single LOD vtx without body parts
+func encodeVtx(t *testing.T, checksum int32) []byte {
+	// Version, vertex cache size, bones per strip and triangle, bones per vertex, checksum,
+	// LOD count, material replacement offset, body part count and offset
+	return encode(t, int32(7), int32(24), uint16(53), uint16(9), int32(3), checksum, int32(1), int32(0), int32(0), int32(36))
+}
+
+// encodePhy returns a phy with a single box solid
+func encodePhy(t *testing.T, checksum int32) []byte {
+	buf := &bytes.Buffer{}
+	definition := &phy.Definition{
+		Solids:   []phy.SolidDefinition{{Hulls: []phy.Hull{cubeHull(mgl32.Vec3{}, 1)}, Properties: phy.Solid{Name: "root", Mass: 1}}},
+		CheckSum: checksum,
+	}
+	if err := phy.NewWriter().Write(buf, definition); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoad(t *testing.T) {
+	const checksum = 321
+
+	// modelFiles returns a complete model, minus any file names in omit
+	modelFiles := func(animBlockName string, omit ...string) fstest.MapFS {
+		files := fstest.MapFS{
+			"models/prop.mdl":       {Data: encodeMdl(t, checksum, animBlockName)},
+			"models/prop.vvd":       {Data: encodeVvd(t, checksum)},
+			"models/prop.dx90.vtx":  {Data: encodeVtx(t, checksum)},
+			"models/prop.phy":       {Data: encodePhy(t, checksum)},
+			"models/prop_anims.ani": {Data: []byte("ani data")},
+		}
+		for _, name := range omit {
+			delete(files, name)
+		}
+		return files
+	}
+
+	cases := []struct {
+		name    string
+		files   fstest.MapFS
+		path    string
+		wantErr error
+		// phy is whether the model should have collision
+		phy bool
+		// badPhy is whether PhyErr should be set, and phyErr what it should wrap
+		badPhy  bool
+		phyErr  error
+		ani     string
+		missing []string
+	}{
+		{name: "complete", files: modelFiles(`models\prop_anims.ani`), path: "models/prop.mdl", phy: true, ani: "ani data"},
+		{name: "no extension", files: modelFiles(""), path: "models/prop", phy: true},
+		{name: "ani from mdl", files: modelFiles("models/prop_anims.ani"), path: "models/prop.mdl", phy: true, ani: "ani data"},
+		{name: "missing phy", files: modelFiles("", "models/prop.phy"), path: "models/prop.mdl", missing: []string{"models/prop.phy"}},
+		{name: "missing ani", files: modelFiles("models/other.ani"), path: "models/prop.mdl", phy: true, missing: []string{"models/other.ani"}},
+		{name: "corrupt phy", files: func() fstest.MapFS {
+			files := modelFiles("")
+			files["models/prop.phy"] = &fstest.MapFile{Data: []byte{1, 2, 3}}
+			return files
+		}(), path: "models/prop.mdl", badPhy: true},
+		{name: "phy checksum mismatch", files: func() fstest.MapFS {
+			files := modelFiles("")
+			files["models/prop.phy"] = &fstest.MapFile{Data: encodePhy(t, checksum+1)}
+			return files
+		}(), path: "models/prop.mdl", badPhy: true, phyErr: ErrChecksumMismatch},
+		{name: "vvd checksum mismatch", files: func() fstest.MapFS {
+			files := modelFiles("")
+			files["models/prop.vvd"] = &fstest.MapFile{Data: encodeVvd(t, checksum+1)}
+			return files
+		}(), path: "models/prop.mdl", wantErr: ErrChecksumMismatch},
+		{name: "missing vvd", files: modelFiles("", "models/prop.vvd"), path: "models/prop.mdl", wantErr: fs.ErrNotExist},
+		{name: "missing vtx", files: modelFiles("", "models/prop.dx90.vtx"), path: "models/prop.mdl", wantErr: fs.ErrNotExist},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := Load(tc.files, tc.path)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+
+			if model.Mdl == nil || model.Vvd == nil || model.Vtx == nil {
+				t.Fatal("model is missing a required file")
+			}
+			if model.HasCollisionModel() != tc.phy {
+				t.Errorf("HasCollisionModel = %v, want %v", model.HasCollisionModel(), tc.phy)
+			}
+			if (model.PhyErr != nil) != tc.badPhy || tc.phyErr != nil && !errors.Is(model.PhyErr, tc.phyErr) {
+				t.Errorf("PhyErr = %v, want set: %v", model.PhyErr, tc.badPhy)
+			}
+			if string(model.Ani) != tc.ani {
+				t.Errorf("ani = %q, want %q", model.Ani, tc.ani)
+			}
+			if len(model.Missing) != len(tc.missing) {
+				t.Fatalf("missing = %v, want %v", model.Missing, tc.missing)
+			}
+			for i := range tc.missing {
+				if model.Missing[i] != tc.missing[i] {
+					t.Errorf("missing = %v, want %v", model.Missing, tc.missing)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package studiomodel
+
+import "math"
+
+// RootLOD returns the most detailed LOD that may be rendered.
+// requested is the preferred root LOD (e.g. a quality setting); it is raised to the
+// mdl's $minlod (Studiohdr.RootLOD) and capped by Studiohdr.NumAllowedRootLods.
+func (model *StudioModel) RootLOD(requested int) int {
+	rootLOD := requested
+	if model.Mdl != nil {
+		header := &model.Mdl.Header
+		if int(header.RootLOD) > rootLOD {
+			rootLOD = int(header.RootLOD)
+		}
+		if header.NumAllowedRootLods > 0 && rootLOD > int(header.NumAllowedRootLods)-1 {
+			rootLOD = int(header.NumAllowedRootLods) - 1
+		}
+	}
+	if rootLOD < 0 {
+		rootLOD = 0
+	}
+	if model.Vtx != nil && model.Vtx.NumLODs > 0 && rootLOD > int(model.Vtx.NumLODs)-1 {
+		rootLOD = int(model.Vtx.NumLODs) - 1
+	}
+	return rootLOD
+}
+
+// LODForMetric returns the LOD to render for an engine LOD metric.
+// The metric grows as the model gets smaller on screen; see LODForScreenSize.
+func (model *StudioModel) LODForMetric(metric float32) int {
+	rootLOD := model.RootLOD(0)
+	if model.Vtx == nil {
+		return rootLOD
+	}
+	// Every model in a vtx shares the same switch points
+	for _, bodyPart := range model.Vtx.BodyParts {
+		for i := range bodyPart.Models {
+			if len(bodyPart.Models[i].LODS) > 0 {
+				return bodyPart.Models[i].LODForMetric(metric, rootLOD)
+			}
+		}
+	}
+	return rootLOD
+}
+
+// LODForScreenSize returns the LOD to render given the on-screen diameter, in pixels,
+// of a sphere one unit across at the model's position
+func (model *StudioModel) LODForScreenSize(unitSphereSize float32) int {
+	metric := float32(0)
+	if unitSphereSize > 0 {
+		metric = 100 / unitSphereSize
+	}
+	return model.LODForMetric(metric)
+}
+
+// LODForDistance returns the LOD to render at a distance from a perspective camera.
+// fovY is the vertical field of view in radians, viewportHeight is in pixels.
+func (model *StudioModel) LODForDistance(distance float32, fovY float32, viewportHeight float32) int {
+	if distance <= 0 {
+		return model.RootLOD(0)
+	}
+	size := 0.5 * viewportHeight / (distance * float32(math.Tan(float64(fovY)*0.5)))
+	return model.LODForScreenSize(size)
+}
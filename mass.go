@@ -0,0 +1,79 @@
+package studiomodel
+
+import (
+	"errors"
+
+	"github.com/galaco/studiomodel/phy"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SolidMass is the mass properties of one collision solid, in Source units relative to its bone
+type SolidMass struct {
+	// Index
+	// Index of the solid in the phy
+	Index int
+	// Bone
+	// Index of the bone in the mdl, -1 if it could not be resolved
+	Bone int
+	// Mass
+	// Mass in kilograms
+	Mass float32
+	// Volume
+	// Volume in cubic inches
+	Volume float32
+	// CenterOfMass
+	CenterOfMass mgl32.Vec3
+	// Inertia
+	// Inertia tensor about CenterOfMass, in kg·in²
+	Inertia mgl32.Mat3
+	// Discrepancy
+	// Computed values compared with those stored in the phy, in IVP units
+	Discrepancy phy.MassDiscrepancy
+}
+
+// MassProperties computes the mass properties of every collision solid.
+// Each solid uses the mass from the phy text section; solids without one share
+// Studiohdr.Mass in proportion to their volume.
+func (model *StudioModel) MassProperties() ([]SolidMass, error) {
+	if model.Phy == nil {
+		return nil, errors.New("model has no collision model")
+	}
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to resolve solid masses")
+	}
+
+	solids := make([]SolidMass, len(model.Phy.Surfaces))
+	props := make([]phy.MassProperties, len(model.Phy.Surfaces))
+	unassignedVolume := float32(0)
+	for i := range model.Phy.Surfaces {
+		resolved := model.resolveSolid(i)
+
+		hulls := model.Phy.Surfaces[i].Hulls()
+		for j := range hulls {
+			hulls[j] = hulls[j].Transform(phy.ToSourceSpace)
+		}
+		props[i] = phy.ComputeMassProperties(hulls)
+
+		solids[i] = SolidMass{
+			Index:        i,
+			Bone:         resolved.Bone,
+			Volume:       props[i].Volume,
+			CenterOfMass: props[i].CenterOfMass,
+			Discrepancy:  model.Phy.Surfaces[i].CompareStoredMass(),
+		}
+		if resolved.Properties != nil && resolved.Properties.Mass > 0 {
+			solids[i].Mass = resolved.Properties.Mass
+		} else {
+			unassignedVolume += props[i].Volume
+		}
+	}
+
+	for i := range solids {
+		if solids[i].Mass == 0 && unassignedVolume > 0 {
+			solids[i].Mass = model.Mdl.Header.Mass * solids[i].Volume / unassignedVolume
+		}
+		solids[i].Inertia = props[i].InertiaForMass(solids[i].Mass)
+	}
+
+	return solids, nil
+}
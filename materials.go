@@ -0,0 +1,31 @@
+package studiomodel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaterialName returns the name of the material a mesh renders with at a LOD.
+// Materials replaced for that LOD in the vtx take precedence over the mdl's texture names.
+func (model *StudioModel) MaterialName(bodyPart, subModel, mesh, lod int) (string, error) {
+	if model.Mdl == nil {
+		return "", errors.New("model requires an mdl to resolve materials")
+	}
+
+	materialIndex, err := model.Mdl.GetMaterialIndexForMesh(bodyPart, subModel, mesh)
+	if err != nil {
+		return "", err
+	}
+
+	if model.Vtx != nil {
+		if name, ok := model.Vtx.ReplacementMaterial(lod, int(materialIndex)); ok {
+			return name, nil
+		}
+	}
+
+	if materialIndex < 0 || int(materialIndex) >= len(model.Mdl.TextureNames) {
+		return "", fmt.Errorf("material index %d out of range (have %d textures)", materialIndex, len(model.Mdl.TextureNames))
+	}
+
+	return model.Mdl.TextureNames[materialIndex], nil
+}
@@ -1,8 +1,10 @@
 package mdl
 
 import (
+	"encoding/binary"
 	"fmt"
 	"github.com/go-gl/mathgl/mgl32"
+	"strings"
 )
 
 // Studiohdr is the Mdl header. Contains offsets and info for different data in this file, and associated formats.
@@ -227,6 +229,21 @@ type Mdl struct {
 	Header Studiohdr
 	// Bones
 	Bones []Bone
+	// BoneNames
+	BoneNames []string //mapped to Bones above.
+	// BoneSurfaceProps
+	// Surface prop name of each bone, empty if unset
+	BoneSurfaceProps []string
+	// SurfaceProp
+	// Surface prop name of the model ($surfaceprop)
+	SurfaceProp string
+	// KeyValues
+	// Raw $keyvalues text, including prop_data. Empty if the model has none
+	KeyValues string
+	// AnimBlockName
+	// Path of the .ani file holding demand loaded animation ($animblocksize), relative to
+	// the game directory. Empty if the model has no animation blocks
+	AnimBlockName string
 	// BoneControllers
 	BoneControllers []BoneController
 	// HitboxSet
@@ -244,6 +261,12 @@ type Mdl struct {
 	// BodyParts - parsed body part hierarchy
 	// Added to expose body part/model/mesh hierarchy with material indices
 	BodyParts []BodyPartData
+	// ByteOrder is the byte order the file was stored in.
+	// binary.BigEndian for Xbox 360 and PS3 (.360.mdl) files
+	ByteOrder binary.ByteOrder
+	// Variant is the name of the registered Variant that decoded this file.
+	// Empty for standard Valve files
+	Variant string
 
 	// Some skin stuff here
 	// @TODO there may be latter properties
@@ -282,3 +305,32 @@ func (mdl *Mdl) GetAllMaterialIndices() []int32 {
 	}
 	return indices
 }
+
+// BoneIndex returns the index of the bone with a name, compared case-insensitively.
+// Returns -1 if no bone matches.
+func (mdl *Mdl) BoneIndex(name string) int {
+	for i, boneName := range mdl.BoneNames {
+		if strings.EqualFold(boneName, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// BindPose returns the bone-to-model transform of every bone in the reference pose.
+// Derived from the inverse of Bone.PoseToBone.
+func (mdl *Mdl) BindPose() []mgl32.Mat4 {
+	pose := make([]mgl32.Mat4, len(mdl.Bones))
+	for i := range mdl.Bones {
+		// matrix3x4_t is stored row-major
+		raw := [12]float32(mdl.Bones[i].PoseToBone)
+		poseToBone := mgl32.Ident4()
+		for row := 0; row < 3; row++ {
+			for col := 0; col < 4; col++ {
+				poseToBone.Set(row, col, raw[row*4+col])
+			}
+		}
+		pose[i] = poseToBone.Inv()
+	}
+	return pose
+}
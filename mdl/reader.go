@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"github.com/galaco/studiomodel/internal"
 	"io"
+	"strings"
 	"unsafe"
 )
 
@@ -19,6 +21,7 @@ const (
 
 // Reader is a parser for mdl files
 type Reader struct {
+	byteOrder binary.ByteOrder
 }
 
 // Read parses the passed stream and returns an Mdl
@@ -31,12 +34,20 @@ func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
 	}
 	buf = byteBuf.Bytes()
 
-	// Validate minimum file size
-	if len(buf) < int(unsafe.Sizeof(Studiohdr{})) {
-		return nil, fmt.Errorf("mdl file too small: %d bytes, expected at least %d", len(buf), unsafe.Sizeof(Studiohdr{}))
+	// Console builds are byte swapped; the magic number tells us which order we have
+	reader.byteOrder, err = internal.DetectByteOrder(buf, MDLMagicNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MDL magic number: %w", err)
 	}
 
-	header, err := reader.readHeader(buf)
+	// Variants may have a smaller header than Studiohdr, so detection runs on a zero padded
+	// copy before the standard size check
+	headerBuf := buf
+	if len(buf) < int(unsafe.Sizeof(Studiohdr{})) {
+		headerBuf = make([]byte, unsafe.Sizeof(Studiohdr{}))
+		copy(headerBuf, buf)
+	}
+	header, err := reader.readHeader(headerBuf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read MDL header: %w", err)
 	}
@@ -46,11 +57,28 @@ func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
 		return nil, fmt.Errorf("invalid MDL magic number: got 0x%08X, expected 0x%08X", header.Id, MDLMagicNumber)
 	}
 
+	// Engine forks reuse the magic number, so registered variants get the first say
+	if variant := findVariant(header, buf); variant != nil {
+		return reader.readVariant(variant, buf, header)
+	}
+
+	// Validate minimum file size
+	if len(buf) < int(unsafe.Sizeof(Studiohdr{})) {
+		return nil, fmt.Errorf("mdl file too small: %d bytes, expected at least %d", len(buf), unsafe.Sizeof(Studiohdr{}))
+	}
+
 	// Validate version
 	if header.Version < MDLMinVersion || header.Version > MDLMaxVersion {
 		return nil, fmt.Errorf("unsupported MDL version: got %d, expected between %d and %d", header.Version, MDLMinVersion, MDLMaxVersion)
 	}
 
+	return reader.decode(buf, header, Sections{})
+}
+
+// decode reads everything following the header, using sections for each table
+func (reader *Reader) decode(buf []byte, header *Studiohdr, sections Sections) (*Mdl, error) {
+	sections = sections.withDefaults()
+
 	// Validate counts are non-negative
 	if header.BoneCount < 0 || header.BoneControllerCount < 0 || header.HitboxCount < 0 ||
 		header.LocalAnimationCount < 0 || header.LocalSequenceCount < 0 || header.TextureCount < 0 || header.TextureDirCount < 0 {
@@ -67,81 +95,87 @@ func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
 	//}
 
 	// Read all properties with bounds checking
-	bones := make([]Bone, header.BoneCount)
-	if header.BoneCount > 0 {
-		boneSize := int32(int(unsafe.Sizeof(Bone{})) * len(bones))
-		if err := validateOffset(buf, header.BoneOffset, boneSize, "bones"); err != nil {
-			return nil, err
-		}
-		err = binary.Read(bytes.NewBuffer(buf[header.BoneOffset:header.BoneOffset+boneSize]), binary.LittleEndian, &bones)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read bones at offset %d: %w", header.BoneOffset, err)
-		}
+	bones, err := sections.Bones(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, err
 	}
 
-	boneControllers := make([]BoneController, header.BoneControllerCount)
-	if header.BoneControllerCount > 0 {
-		boneControllerSize := int32(int(unsafe.Sizeof(BoneController{})) * len(boneControllers))
-		if err := validateOffset(buf, header.BoneControllerOffset, boneControllerSize, "bone controllers"); err != nil {
-			return nil, err
+	boneNames := make([]string, len(bones))
+	boneSurfaceProps := make([]string, len(bones))
+	for i := range bones {
+		boneOffset := header.BoneOffset + sections.BoneSize*int32(i)
+		nameOffset := boneOffset + bones[i].NameIndex
+		if nameOffset < 0 || int(nameOffset) >= len(buf) {
+			return nil, fmt.Errorf("bone %d name offset %d out of bounds", i, nameOffset)
 		}
-		err = binary.Read(bytes.NewBuffer(buf[header.BoneControllerOffset:header.BoneControllerOffset+boneControllerSize]), binary.LittleEndian, &boneControllers)
+		name, err := readCString(buf, int(nameOffset), 256)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read bone controllers at offset %d: %w", header.BoneControllerOffset, err)
+			return nil, fmt.Errorf("failed to read bone %d name at offset %d: %w", i, nameOffset, err)
 		}
-	}
+		boneNames[i] = name
 
-	hitboxSets := make([]HitboxSet, header.HitboxCount)
-	if header.HitboxCount > 0 {
-		hitboxSetSize := int32(int(unsafe.Sizeof(HitboxSet{})) * len(hitboxSets))
-		if err := validateOffset(buf, header.HitboxOffset, hitboxSetSize, "hitbox sets"); err != nil {
-			return nil, err
-		}
-		err = binary.Read(bytes.NewBuffer(buf[header.HitboxOffset:header.HitboxOffset+hitboxSetSize]), binary.LittleEndian, &hitboxSets)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read hitbox sets at offset %d: %w", header.HitboxOffset, err)
+		// SurfacePropIndex is relative to the bone, 0 if the bone has no surface prop
+		if bones[i].SurfacePropIndex != 0 {
+			boneSurfaceProps[i], err = readCString(buf, int(boneOffset+bones[i].SurfacePropIndex), 256)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read bone %d surface prop: %w", i, err)
+			}
 		}
 	}
 
-	animDescs := make([]AnimDesc, header.LocalAnimationCount)
-	if header.LocalAnimationCount > 0 {
-		animDescSize := int32(int(unsafe.Sizeof(AnimDesc{})) * len(animDescs))
-		if err := validateOffset(buf, header.LocalAnimationOffset, animDescSize, "animation descriptions"); err != nil {
+	// $keyvalues text, KeyValueCount is its size in bytes
+	keyValues := ""
+	if header.KeyValueCount > 0 {
+		if err := validateOffset(buf, header.KeyValueIndex, header.KeyValueCount, "key values"); err != nil {
 			return nil, err
 		}
-		err = binary.Read(bytes.NewBuffer(buf[header.LocalAnimationOffset:header.LocalAnimationOffset+animDescSize]), binary.LittleEndian, &animDescs)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read animation descriptions at offset %d: %w", header.LocalAnimationOffset, err)
-		}
+		keyValues = strings.TrimRight(string(buf[header.KeyValueIndex:header.KeyValueIndex+header.KeyValueCount]), "\x00")
 	}
 
-	sequenceDescs := make([]SequenceDesc, header.LocalSequenceCount)
-	if header.LocalSequenceCount > 0 {
-		sequenceDescSize := int32(int(unsafe.Sizeof(SequenceDesc{})) * len(sequenceDescs))
-		if err := validateOffset(buf, header.LocalSequenceOffset, sequenceDescSize, "sequence descriptions"); err != nil {
-			return nil, err
-		}
-		err = binary.Read(bytes.NewBuffer(buf[header.LocalSequenceOffset:header.LocalSequenceOffset+sequenceDescSize]), binary.LittleEndian, &sequenceDescs)
+	animBlockName := ""
+	if header.AnimblocksCount > 0 && header.AnimblocksNameIndex != 0 {
+		animBlockName, err = readCString(buf, int(header.AnimblocksNameIndex), 256)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read sequence descriptions at offset %d: %w", header.LocalSequenceOffset, err)
+			return nil, fmt.Errorf("failed to read anim block name: %w", err)
 		}
 	}
 
-	textures := make([]Texture, header.TextureCount)
-	if header.TextureCount > 0 {
-		textureSize := int32(int(unsafe.Sizeof(Texture{})) * len(textures))
-		if err := validateOffset(buf, header.TextureOffset, textureSize, "textures"); err != nil {
-			return nil, err
-		}
-		err = binary.Read(bytes.NewBuffer(buf[header.TextureOffset:header.TextureOffset+textureSize]), binary.LittleEndian, &textures)
+	surfaceProp := ""
+	if header.SurfacePropertyIndex != 0 {
+		surfaceProp, err = readCString(buf, int(header.SurfacePropertyIndex), 256)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read textures at offset %d: %w", header.TextureOffset, err)
+			return nil, fmt.Errorf("failed to read surface prop: %w", err)
 		}
 	}
 
-	textureNames := make([]string, header.TextureCount)
+	boneControllers, err := sections.BoneControllers(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	hitboxSets, err := sections.HitboxSets(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	animDescs, err := sections.AnimDescs(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	sequenceDescs, err := sections.SequenceDescs(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	textures, err := sections.Textures(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	textureNames := make([]string, len(textures))
 	for i := range textures {
-		nameOffset := header.TextureOffset + int32(int(unsafe.Sizeof(Texture{}))*i) + textures[i].NameIndex
+		nameOffset := header.TextureOffset + sections.TextureSize*int32(i) + textures[i].NameIndex
 		if nameOffset < 0 || int(nameOffset) >= len(buf) {
 			return nil, fmt.Errorf("texture %d name offset %d out of bounds", i, nameOffset)
 		}
@@ -152,19 +186,12 @@ func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
 		textureNames[i] = name
 	}
 
-	textureDirOffsets := make([]int32, header.TextureDirCount)
-	if header.TextureDirCount > 0 {
-		textureDirOffsetsSize := int32(int(unsafe.Sizeof(int32(0))) * len(textureDirOffsets))
-		if err := validateOffset(buf, header.TextureDirOffset, textureDirOffsetsSize, "texture directory offsets"); err != nil {
-			return nil, err
-		}
-		err = binary.Read(bytes.NewBuffer(buf[header.TextureDirOffset:header.TextureDirOffset+textureDirOffsetsSize]), binary.LittleEndian, &textureDirOffsets)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read texture directory offsets at %d: %w", header.TextureDirOffset, err)
-		}
+	textureDirOffsets, err := readTable[int32](buf, header.TextureDirOffset, header.TextureDirCount, reader.byteOrder, "texture directory offsets")
+	if err != nil {
+		return nil, err
 	}
 
-	textureDirs := make([]string, header.TextureDirCount)
+	textureDirs := make([]string, len(textureDirOffsets))
 	for i, offset := range textureDirOffsets {
 		if offset < 0 || int(offset) >= len(buf) {
 			return nil, fmt.Errorf("texture directory %d offset %d out of bounds", i, offset)
@@ -177,58 +204,28 @@ func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
 	}
 
 	// Parse body parts hierarchy (body parts → models → meshes)
-	var bodyParts []BodyPartData
-	if header.BodyPartCount > 0 {
-		bodyPartHeaders, err := reader.readBodyParts(buf, header)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse body parts: %w", err)
-		}
-
-		// Parse models and meshes for each body part
-		bodyParts = make([]BodyPartData, len(bodyPartHeaders))
-		for i, bodyPartHeader := range bodyPartHeaders {
-			bodyPartOffset := header.BodypartOffset + int32(i)*int32(unsafe.Sizeof(BodyPart{}))
-
-			// Read models for this body part
-			models, err := reader.readModelsForBodyPart(buf, &bodyPartHeader, bodyPartOffset)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse models for body part %d: %w", i, err)
-			}
-
-			// Parse meshes for each model
-			modelData := make([]ModelData, len(models))
-			for j, model := range models {
-				modelOffset := bodyPartOffset + bodyPartHeader.ModelIndex + int32(j)*int32(unsafe.Sizeof(Model{}))
-
-				meshes, err := reader.readMeshesForModel(buf, &model, modelOffset)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse meshes for model %d in body part %d: %w", j, i, err)
-				}
-
-				modelData[j] = ModelData{
-					Header: model,
-					Meshes: meshes,
-				}
-			}
-
-			bodyParts[i] = BodyPartData{
-				Header: bodyPartHeader,
-				Models: modelData,
-			}
-		}
+	bodyParts, err := sections.BodyParts(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse body parts: %w", err)
 	}
 
 	return &Mdl{
-		Header:          *header,
-		Bones:           bones,
-		BoneControllers: boneControllers,
-		HitboxSet:       hitboxSets,
-		AnimDescs:       animDescs,
-		SequenceDescs:   sequenceDescs,
-		Textures:        textures,
-		TextureNames:    textureNames,
-		TextureDirs:     textureDirs,
-		BodyParts:       bodyParts,
+		Header:           *header,
+		Bones:            bones,
+		BoneNames:        boneNames,
+		BoneSurfaceProps: boneSurfaceProps,
+		SurfaceProp:      surfaceProp,
+		KeyValues:        keyValues,
+		AnimBlockName:    animBlockName,
+		BoneControllers:  boneControllers,
+		HitboxSet:        hitboxSets,
+		AnimDescs:        animDescs,
+		SequenceDescs:    sequenceDescs,
+		Textures:         textures,
+		TextureNames:     textureNames,
+		TextureDirs:      textureDirs,
+		BodyParts:        bodyParts,
+		ByteOrder:        reader.byteOrder,
 	}, nil
 }
 
@@ -236,8 +233,11 @@ func (reader *Reader) Read(stream io.Reader) (*Mdl, error) {
 func (reader *Reader) readHeader(buf []byte) (*Studiohdr, error) {
 	header := Studiohdr{}
 	headerSize := unsafe.Sizeof(header)
+	if len(buf) < int(headerSize) {
+		return nil, fmt.Errorf("header needs %d bytes, have %d", headerSize, len(buf))
+	}
 
-	err := binary.Read(bytes.NewBuffer(buf[:headerSize]), binary.LittleEndian, &header)
+	err := binary.Read(bytes.NewBuffer(buf[:headerSize]), reader.byteOrder, &header)
 
 	return &header, err
 }
@@ -297,7 +297,7 @@ func (reader *Reader) readBodyParts(buf []byte, header *Studiohdr) ([]BodyPart,
 
 	err := binary.Read(
 		bytes.NewBuffer(buf[header.BodypartOffset:header.BodypartOffset+totalSize]),
-		binary.LittleEndian,
+		reader.byteOrder,
 		&bodyParts,
 	)
 	if err != nil {
@@ -326,7 +326,7 @@ func (reader *Reader) readModelsForBodyPart(buf []byte, bodyPart *BodyPart, body
 
 	err := binary.Read(
 		bytes.NewBuffer(buf[modelOffset:modelOffset+totalSize]),
-		binary.LittleEndian,
+		reader.byteOrder,
 		&models,
 	)
 	if err != nil {
@@ -348,14 +348,14 @@ func (reader *Reader) readMeshesForModel(buf []byte, model *Model, modelOffset i
 
 	// MeshIndex is relative to the model offset
 	meshOffset := modelOffset + model.MeshIndex
-	
+
 	if err := validateOffset(buf, meshOffset, totalSize, "meshes"); err != nil {
 		return nil, err
 	}
 
 	err := binary.Read(
 		bytes.NewBuffer(buf[meshOffset:meshOffset+totalSize]),
-		binary.LittleEndian,
+		reader.byteOrder,
 		&meshes,
 	)
 	if err != nil {
@@ -0,0 +1,173 @@
+package mdl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+)
+
+// SectionDecoder decodes one table of an mdl file
+type SectionDecoder[T any] func(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]T, error)
+
+// Sections are the per table decoders used to build an Mdl.
+// Nil decoders use the standard layout, so a variant only supplies the tables it changes.
+type Sections struct {
+	// Bones
+	Bones SectionDecoder[Bone]
+	// BoneSize
+	// On-disk size of a bone, used to resolve Bone.NameIndex and Bone.SurfacePropIndex.
+	// The standard size when 0
+	BoneSize int32
+	// BoneControllers
+	BoneControllers SectionDecoder[BoneController]
+	// HitboxSets
+	HitboxSets SectionDecoder[HitboxSet]
+	// AnimDescs
+	AnimDescs SectionDecoder[AnimDesc]
+	// SequenceDescs
+	SequenceDescs SectionDecoder[SequenceDesc]
+	// Textures
+	Textures SectionDecoder[Texture]
+	// TextureSize
+	// On-disk size of a texture, used to resolve Texture.NameIndex. The standard size when 0
+	TextureSize int32
+	// BodyParts
+	// Decodes the body part → model → mesh hierarchy
+	BodyParts SectionDecoder[BodyPartData]
+}
+
+// withDefaults returns a copy with every unset decoder replaced by the standard one
+func (sections Sections) withDefaults() Sections {
+	if sections.Bones == nil {
+		sections.Bones = ReadBones
+	}
+	if sections.BoneSize == 0 {
+		sections.BoneSize = int32(unsafe.Sizeof(Bone{}))
+	}
+	if sections.BoneControllers == nil {
+		sections.BoneControllers = ReadBoneControllers
+	}
+	if sections.HitboxSets == nil {
+		sections.HitboxSets = ReadHitboxSets
+	}
+	if sections.AnimDescs == nil {
+		sections.AnimDescs = ReadAnimDescs
+	}
+	if sections.SequenceDescs == nil {
+		sections.SequenceDescs = ReadSequenceDescs
+	}
+	if sections.Textures == nil {
+		sections.Textures = ReadTextures
+	}
+	if sections.TextureSize == 0 {
+		sections.TextureSize = int32(unsafe.Sizeof(Texture{}))
+	}
+	if sections.BodyParts == nil {
+		sections.BodyParts = ReadBodyParts
+	}
+	return sections
+}
+
+// ReadBones decodes the standard bone table
+func ReadBones(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]Bone, error) {
+	return readTable[Bone](buf, header.BoneOffset, header.BoneCount, byteOrder, "bones")
+}
+
+// ReadBoneControllers decodes the standard bone controller table
+func ReadBoneControllers(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]BoneController, error) {
+	return readTable[BoneController](buf, header.BoneControllerOffset, header.BoneControllerCount, byteOrder, "bone controllers")
+}
+
+// ReadHitboxSets decodes the standard hitbox set table
+func ReadHitboxSets(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]HitboxSet, error) {
+	return readTable[HitboxSet](buf, header.HitboxOffset, header.HitboxCount, byteOrder, "hitbox sets")
+}
+
+// ReadAnimDescs decodes the standard animation description table
+func ReadAnimDescs(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]AnimDesc, error) {
+	return readTable[AnimDesc](buf, header.LocalAnimationOffset, header.LocalAnimationCount, byteOrder, "animation descriptions")
+}
+
+// ReadSequenceDescs decodes the standard sequence description table
+func ReadSequenceDescs(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]SequenceDesc, error) {
+	return readTable[SequenceDesc](buf, header.LocalSequenceOffset, header.LocalSequenceCount, byteOrder, "sequence descriptions")
+}
+
+// ReadTextures decodes the standard texture table
+func ReadTextures(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]Texture, error) {
+	return readTable[Texture](buf, header.TextureOffset, header.TextureCount, byteOrder, "textures")
+}
+
+// ReadBodyParts decodes the standard body part → model → mesh hierarchy
+func ReadBodyParts(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]BodyPartData, error) {
+	reader := &Reader{byteOrder: byteOrder}
+	if header.BodyPartCount <= 0 {
+		return nil, nil
+	}
+
+	bodyPartHeaders, err := reader.readBodyParts(buf, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse models and meshes for each body part
+	bodyParts := make([]BodyPartData, len(bodyPartHeaders))
+	for i, bodyPartHeader := range bodyPartHeaders {
+		bodyPartOffset := header.BodypartOffset + int32(i)*int32(unsafe.Sizeof(BodyPart{}))
+
+		// Read models for this body part
+		models, err := reader.readModelsForBodyPart(buf, &bodyPartHeader, bodyPartOffset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse models for body part %d: %w", i, err)
+		}
+
+		// Parse meshes for each model
+		modelData := make([]ModelData, len(models))
+		for j, model := range models {
+			modelOffset := bodyPartOffset + bodyPartHeader.ModelIndex + int32(j)*int32(unsafe.Sizeof(Model{}))
+
+			meshes, err := reader.readMeshesForModel(buf, &model, modelOffset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse meshes for model %d in body part %d: %w", j, i, err)
+			}
+
+			modelData[j] = ModelData{
+				Header: model,
+				Meshes: meshes,
+			}
+		}
+
+		bodyParts[i] = BodyPartData{
+			Header: bodyPartHeader,
+			Models: modelData,
+		}
+	}
+
+	return bodyParts, nil
+}
+
+// readTable decodes count consecutive structs at offset, checking bounds before allocating
+func readTable[T any](buf []byte, offset int32, count int32, byteOrder binary.ByteOrder, name string) ([]T, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("%s count is negative: %d", name, count)
+	}
+	if count == 0 {
+		return make([]T, 0), nil
+	}
+
+	var zero T
+	size := int64(unsafe.Sizeof(zero)) * int64(count)
+	if size > int64(len(buf)) {
+		return nil, fmt.Errorf("%s data exceeds buffer: count=%d size=%d bufferSize=%d", name, count, size, len(buf))
+	}
+	if err := validateOffset(buf, offset, int32(size), name); err != nil {
+		return nil, err
+	}
+
+	out := make([]T, count)
+	if err := binary.Read(bytes.NewBuffer(buf[offset:int64(offset)+size]), byteOrder, &out); err != nil {
+		return nil, fmt.Errorf("failed to read %s at offset %d: %w", name, offset, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,160 @@
+package mdl
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Variant describes an engine fork that reuses the IDST magic number with its own layout
+// (Vindictus, The Ship, Dark Messiah, Bloody Good Time, Titanfall etc.).
+type Variant struct {
+	// Name identifies the variant. It is recorded on Mdl.Variant
+	Name string
+	// Detect reports whether a file belongs to this variant.
+	// header is decoded with the standard layout, so only Id, Version and Checksum are
+	// guaranteed to be meaningful. It runs before any size check, and buf may be shorter
+	// than Studiohdr.
+	Detect func(header *Studiohdr, buf []byte) bool
+	// ReadHeader decodes the variant's header.
+	// Optional; the standard Studiohdr layout is used when nil
+	ReadHeader func(buf []byte, byteOrder binary.ByteOrder) (*Studiohdr, error)
+	// Sections replaces the decoders of individual tables, for variants that only change
+	// some structs. Ignored when Decode is set
+	Sections Sections
+	// Decode builds an Mdl from the file.
+	// Optional; DecodeSections with Sections is used when nil
+	Decode func(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) (*Mdl, error)
+}
+
+var (
+	variantsMu sync.RWMutex
+	variants   []Variant
+)
+
+// RegisterVariant adds a variant to the registry.
+// Variants are tried in registration order, before the standard version check.
+func RegisterVariant(variant Variant) error {
+	if variant.Name == "" {
+		return errors.New("variant name must not be empty")
+	}
+	if variant.Detect == nil {
+		return fmt.Errorf("variant %s has no Detect function", variant.Name)
+	}
+
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+
+	for _, v := range variants {
+		if v.Name == variant.Name {
+			return fmt.Errorf("variant %s is already registered", variant.Name)
+		}
+	}
+	variants = append(variants, variant)
+
+	return nil
+}
+
+// UnregisterVariant removes a variant from the registry
+func UnregisterVariant(name string) {
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+
+	for i, v := range variants {
+		if v.Name == name {
+			variants = append(variants[:i], variants[i+1:]...)
+			return
+		}
+	}
+}
+
+// RegisteredVariants returns the names of all registered variants, in detection order
+func RegisteredVariants() []string {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+
+	names := make([]string, len(variants))
+	for i, v := range variants {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// DetectVersion returns a Detect function matching any of the passed versions
+func DetectVersion(versions ...int32) func(header *Studiohdr, buf []byte) bool {
+	return func(header *Studiohdr, buf []byte) bool {
+		for _, version := range versions {
+			if header.Version == version {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ReadStandardHeader decodes a header using the standard Studiohdr layout.
+// Useful to variants that only change later structures.
+func ReadStandardHeader(buf []byte, byteOrder binary.ByteOrder) (*Studiohdr, error) {
+	reader := &Reader{byteOrder: byteOrder}
+	return reader.readHeader(buf)
+}
+
+// DecodeStandard decodes the file body using the standard layout.
+// Variants may call this and then patch the result.
+func DecodeStandard(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) (*Mdl, error) {
+	return DecodeSections(buf, header, byteOrder, Sections{})
+}
+
+// DecodeSections decodes the file body, using sections for each table and the standard
+// layout for any table sections leaves unset
+func DecodeSections(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder, sections Sections) (*Mdl, error) {
+	reader := &Reader{byteOrder: byteOrder}
+	return reader.decode(buf, header, sections)
+}
+
+// findVariant returns the first registered variant that claims the file, or nil
+func findVariant(header *Studiohdr, buf []byte) *Variant {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+
+	for i := range variants {
+		if variants[i].Detect(header, buf) {
+			variant := variants[i]
+			return &variant
+		}
+	}
+	return nil
+}
+
+// readVariant decodes a file using a variant's header and struct decoders
+func (reader *Reader) readVariant(variant *Variant, buf []byte, header *Studiohdr) (*Mdl, error) {
+	var err error
+	if variant.ReadHeader != nil {
+		header, err = variant.ReadHeader(buf, reader.byteOrder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s MDL header: %w", variant.Name, err)
+		}
+	}
+
+	decode := variant.Decode
+	if decode == nil {
+		decode = func(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) (*Mdl, error) {
+			return DecodeSections(buf, header, byteOrder, variant.Sections)
+		}
+	}
+
+	out, err := decode(buf, header, reader.byteOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s MDL: %w", variant.Name, err)
+	}
+	if out == nil {
+		return nil, fmt.Errorf("%s decoder returned no data", variant.Name)
+	}
+	out.Variant = variant.Name
+	if out.ByteOrder == nil {
+		out.ByteOrder = reader.byteOrder
+	}
+
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package mdl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// registerTestVariant registers a variant for the duration of a test
+func registerTestVariant(t *testing.T, variant Variant) {
+	t.Helper()
+	if err := RegisterVariant(variant); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { UnregisterVariant(variant.Name) })
+}
+
+func TestVariantDetectedBeforeSizeCheck(t *testing.T) {
+	registerTestVariant(t, Variant{
+		Name:   "tiny",
+		Detect: DetectVersion(1),
+		ReadHeader: func(buf []byte, byteOrder binary.ByteOrder) (*Studiohdr, error) {
+			return &Studiohdr{Id: MDLMagicNumber, Version: 1, Checksum: int32(byteOrder.Uint32(buf[8:]))}, nil
+		},
+		Decode: func(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) (*Mdl, error) {
+			return &Mdl{Header: *header}, nil
+		},
+	})
+
+	// Far smaller than Studiohdr
+	buf := []byte{'I', 'D', 'S', 'T', 1, 0, 0, 0, 42, 0, 0, 0, 0, 0, 0, 0}
+	out, err := ReadFromStream(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if out.Variant != "tiny" || out.Header.Checksum != 42 {
+		t.Errorf("got variant %q checksum %d", out.Variant, out.Header.Checksum)
+	}
+
+	// Without a variant the same file is rejected for its size
+	buf[4] = 48
+	if _, err := ReadFromStream(bytes.NewReader(buf)); err == nil || !strings.Contains(err.Error(), "too small") {
+		t.Errorf("error = %v, want a size error", err)
+	}
+}
+
+func TestVariantSections(t *testing.T) {
+	// A standard header followed by a bone name. Only the bone table is overridden;
+	// every other table comes from the standard decoders
+	headerSize := int32(unsafe.Sizeof(Studiohdr{}))
+	header := Studiohdr{Id: MDLMagicNumber, Version: 48, Checksum: 77}
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.LittleEndian, header); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("pelvis\x00")
+
+	registerTestVariant(t, Variant{
+		Name: "custom bones",
+		Detect: func(header *Studiohdr, buf []byte) bool {
+			return header.Checksum == 77
+		},
+		Sections: Sections{
+			Bones: func(buf []byte, header *Studiohdr, byteOrder binary.ByteOrder) ([]Bone, error) {
+				// Resolved relative to BoneOffset (0 here) plus the bone's index times BoneSize
+				return []Bone{{NameIndex: headerSize}}, nil
+			},
+		},
+	})
+
+	out, err := ReadFromStream(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if out.Variant != "custom bones" {
+		t.Errorf("variant = %q", out.Variant)
+	}
+	if len(out.BoneNames) != 1 || out.BoneNames[0] != "pelvis" {
+		t.Errorf("bone names = %v", out.BoneNames)
+	}
+	if len(out.Textures) != 0 || out.BodyParts != nil {
+		t.Errorf("standard sections decoded unexpected data: %d textures, %d body parts", len(out.Textures), len(out.BodyParts))
+	}
+}
+
+func TestReadTableRejectsHugeCounts(t *testing.T) {
+	buf := make([]byte, 64)
+	if _, err := readTable[Bone](buf, 0, 1<<30, binary.LittleEndian, "bones"); err == nil {
+		t.Error("expected an error for a count larger than the buffer")
+	}
+	if _, err := readTable[Bone](buf, 0, -1, binary.LittleEndian, "bones"); err == nil {
+		t.Error("expected an error for a negative count")
+	}
+}
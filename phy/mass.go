@@ -0,0 +1,255 @@
+package phy
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MassProperties are the mass properties of a solid of uniform unit density.
+// Units follow the vertices they were computed from.
+type MassProperties struct {
+	// Volume
+	Volume float32
+	// CenterOfMass
+	CenterOfMass mgl32.Vec3
+	// Inertia
+	// Inertia tensor about CenterOfMass, for a density of 1
+	Inertia mgl32.Mat3
+}
+
+// InertiaForMass returns the inertia tensor for a total mass, rather than unit density
+func (props *MassProperties) InertiaForMass(mass float32) mgl32.Mat3 {
+	if props.Volume <= 0 {
+		return mgl32.Mat3{}
+	}
+	return props.Inertia.Mul(mass / props.Volume)
+}
+
+// PrincipalMoments returns the principal moments of inertia per unit mass, the eigenvalues
+// of the inertia tensor divided by volume.
+// Moments of a tensor that is already diagonal are returned in axis order.
+func (props *MassProperties) PrincipalMoments() mgl32.Vec3 {
+	if props.Volume <= 0 {
+		return mgl32.Vec3{}
+	}
+	var tensor [3][3]float64
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			tensor[r][c] = float64(props.Inertia.At(r, c)) / float64(props.Volume)
+		}
+	}
+	eigenvalues := symmetricEigenvalues(tensor)
+	return mgl32.Vec3{float32(eigenvalues[0]), float32(eigenvalues[1]), float32(eigenvalues[2])}
+}
+
+// symmetricEigenvalues returns the eigenvalues of a symmetric 3x3 matrix using cyclic Jacobi rotations
+func symmetricEigenvalues(m [3][3]float64) [3]float64 {
+	for sweep := 0; sweep < 50; sweep++ {
+		offDiagonal := m[0][1]*m[0][1] + m[0][2]*m[0][2] + m[1][2]*m[1][2]
+		if offDiagonal < 1e-30 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				// Rotation angle that zeroes m[p][q]
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := 0; k < 3; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p] = c*mkp - s*mkq
+					m[k][q] = s*mkp + c*mkq
+				}
+				for k := 0; k < 3; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k] = c*mpk - s*mqk
+					m[q][k] = s*mpk + c*mqk
+				}
+			}
+		}
+	}
+	return [3]float64{m[0][0], m[1][1], m[2][2]}
+}
+
+// MassDiscrepancy compares computed mass properties with the values stored in a compact surface
+type MassDiscrepancy struct {
+	// CenterOffset
+	// Distance between the computed and stored centres of mass
+	CenterOffset float32
+	// Computed
+	// Principal moments per unit mass, computed from the hulls
+	Computed mgl32.Vec3
+	// Stored
+	// CompactSurface.RotationInertia
+	Stored mgl32.Vec3
+	// InertiaError
+	// Largest relative difference between Computed and Stored, compared smallest to
+	// largest since the two may list the principal axes in a different order
+	InertiaError float32
+}
+
+// massAccumulator sums volume integrals about the origin, in double precision
+type massAccumulator struct {
+	volume     float64
+	first      [3]float64
+	covariance [3][3]float64
+}
+
+// addHull integrates a closed triangle mesh. Winding is normalised so the hull has positive volume.
+func (acc *massAccumulator) addHull(vertices []mgl32.Vec3, triangles [][3]uint16) {
+	hull := massAccumulator{}
+	for _, triangle := range triangles {
+		if int(triangle[0]) >= len(vertices) || int(triangle[1]) >= len(vertices) || int(triangle[2]) >= len(vertices) {
+			continue
+		}
+		var v [3][3]float64
+		for i, index := range triangle {
+			for j := 0; j < 3; j++ {
+				v[i][j] = float64(vertices[index][j])
+			}
+		}
+
+		// Signed volume of the tetrahedron formed with the origin
+		det := v[0][0]*(v[1][1]*v[2][2]-v[1][2]*v[2][1]) -
+			v[0][1]*(v[1][0]*v[2][2]-v[1][2]*v[2][0]) +
+			v[0][2]*(v[1][0]*v[2][1]-v[1][1]*v[2][0])
+		volume := det / 6
+
+		var sum [3]float64
+		for j := 0; j < 3; j++ {
+			sum[j] = v[0][j] + v[1][j] + v[2][j]
+			hull.first[j] += volume * sum[j] / 4
+		}
+		// Covariance of a tetrahedron with a vertex at the origin:
+		// V/20 * (sum of v v^T + s s^T), where s is the sum of the other vertices
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				value := sum[r] * sum[c]
+				for i := 0; i < 3; i++ {
+					value += v[i][r] * v[i][c]
+				}
+				hull.covariance[r][c] += volume / 20 * value
+			}
+		}
+		hull.volume += volume
+	}
+
+	sign := 1.0
+	if hull.volume < 0 {
+		sign = -1
+	}
+	acc.volume += sign * hull.volume
+	for r := 0; r < 3; r++ {
+		acc.first[r] += sign * hull.first[r]
+		for c := 0; c < 3; c++ {
+			acc.covariance[r][c] += sign * hull.covariance[r][c]
+		}
+	}
+}
+
+// properties resolves the accumulated integrals into mass properties about the centre of mass
+func (acc *massAccumulator) properties() MassProperties {
+	if acc.volume <= 0 {
+		return MassProperties{}
+	}
+
+	var center [3]float64
+	for i := range center {
+		center[i] = acc.first[i] / acc.volume
+	}
+
+	// Move the covariance to the centre of mass, then convert to an inertia tensor
+	var covariance [3][3]float64
+	trace := 0.0
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			covariance[r][c] = acc.covariance[r][c] - acc.volume*center[r]*center[c]
+		}
+		trace += covariance[r][r]
+	}
+
+	props := MassProperties{
+		Volume:       float32(acc.volume),
+		CenterOfMass: mgl32.Vec3{float32(center[0]), float32(center[1]), float32(center[2])},
+	}
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			value := -covariance[r][c]
+			if r == c {
+				value += trace
+			}
+			props.Inertia.Set(r, c, float32(value))
+		}
+	}
+
+	return props
+}
+
+// MassProperties computes the unit density mass properties of a single hull
+func (hull *Hull) MassProperties() MassProperties {
+	acc := massAccumulator{}
+	acc.addHull(hull.Vertices, hull.Triangles)
+	return acc.properties()
+}
+
+// ComputeMassProperties computes the combined unit density mass properties of a set of hulls
+func ComputeMassProperties(hulls []Hull) MassProperties {
+	acc := massAccumulator{}
+	for i := range hulls {
+		acc.addHull(hulls[i].Vertices, hulls[i].Triangles)
+	}
+	return acc.properties()
+}
+
+// MassProperties computes the unit density mass properties of every ledge of the surface,
+// in IVP space
+func (surface *CompactSurface) MassProperties() MassProperties {
+	return ComputeMassProperties(surface.Hulls())
+}
+
+// CompareStoredMass compares mass properties computed from the ledges with the
+// MassCenter and RotationInertia values stored in the surface
+func (surface *CompactSurface) CompareStoredMass() MassDiscrepancy {
+	props := surface.MassProperties()
+
+	discrepancy := MassDiscrepancy{
+		CenterOffset: props.CenterOfMass.Sub(surface.MassCenter).Len(),
+		Stored:       surface.RotationInertia,
+	}
+	discrepancy.Computed = props.PrincipalMoments()
+
+	computed := sortedMoments(discrepancy.Computed)
+	stored := sortedMoments(discrepancy.Stored)
+	for i := 0; i < 3; i++ {
+		scale := float32(math.Max(math.Abs(float64(stored[i])), math.Abs(float64(computed[i]))))
+		if scale == 0 {
+			continue
+		}
+		relative := float32(math.Abs(float64(computed[i]-stored[i]))) / scale
+		if relative > discrepancy.InertiaError {
+			discrepancy.InertiaError = relative
+		}
+	}
+
+	return discrepancy
+}
+
+// sortedMoments returns principal moments in ascending order
+func sortedMoments(moments mgl32.Vec3) mgl32.Vec3 {
+	out := moments
+	for i := 1; i < 3; i++ {
+		for j := i; j > 0 && out[j] < out[j-1]; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
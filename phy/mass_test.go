@@ -0,0 +1,83 @@
+package phy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// boxHull returns an axis aligned box hull between min and max, wound outwards
+func boxHull(min, max mgl32.Vec3) Hull {
+	vertices := make([]mgl32.Vec3, 8)
+	for i := range vertices {
+		for axis := 0; axis < 3; axis++ {
+			vertices[i][axis] = min[axis]
+			if i&(1<<axis) != 0 {
+				vertices[i][axis] = max[axis]
+			}
+		}
+	}
+	return Hull{
+		Vertices: vertices,
+		Triangles: [][3]uint16{
+			{0, 2, 3}, {0, 3, 1}, // -z
+			{4, 5, 7}, {4, 7, 6}, // +z
+			{0, 1, 5}, {0, 5, 4}, // -y
+			{2, 6, 7}, {2, 7, 3}, // +y
+			{0, 4, 6}, {0, 6, 2}, // -x
+			{1, 3, 7}, {1, 7, 5}, // +x
+		},
+	}
+}
+
+func approxEqual(a, b, tolerance float32) bool {
+	return float32(math.Abs(float64(a-b))) <= tolerance
+}
+
+func TestHullMassProperties(t *testing.T) {
+	cases := []struct {
+		name    string
+		min     mgl32.Vec3
+		max     mgl32.Vec3
+		volume  float32
+		center  mgl32.Vec3
+		moments mgl32.Vec3
+	}{
+		// Per unit mass moments of a box are (b²+c²)/12 over its side lengths
+		{"cube", mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}, 8, mgl32.Vec3{}, mgl32.Vec3{8.0 / 12, 8.0 / 12, 8.0 / 12}},
+		{"offset box", mgl32.Vec3{1, 2, 3}, mgl32.Vec3{3, 6, 9}, 48, mgl32.Vec3{2, 4, 6}, mgl32.Vec3{52.0 / 12, 40.0 / 12, 20.0 / 12}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hull := boxHull(tc.min, tc.max)
+			props := hull.MassProperties()
+			if !approxEqual(props.Volume, tc.volume, 1e-4) {
+				t.Errorf("volume = %v, want %v", props.Volume, tc.volume)
+			}
+			if !props.CenterOfMass.ApproxEqualThreshold(tc.center, 1e-4) {
+				t.Errorf("center = %v, want %v", props.CenterOfMass, tc.center)
+			}
+			if moments := props.PrincipalMoments(); !moments.ApproxEqualThreshold(tc.moments, 1e-4) {
+				t.Errorf("principal moments = %v, want %v", moments, tc.moments)
+			}
+		})
+	}
+}
+
+func TestPrincipalMomentsOfRotatedTensor(t *testing.T) {
+	// A diagonal tensor rotated off its axes must give back the same principal moments,
+	// whereas its diagonal does not
+	diagonal := mgl32.Diag3(mgl32.Vec3{1, 2, 3})
+	rotation := mgl32.HomogRotate3D(0.6, mgl32.Vec3{1, 2, 3}.Normalize()).Mat3()
+	props := MassProperties{Volume: 2, Inertia: rotation.Mul3(diagonal).Mul3(rotation.Transpose()).Mul(2)}
+
+	if diag := props.Inertia.Mul(0.5).Diag(); diag.ApproxEqualThreshold(mgl32.Vec3{1, 2, 3}, 1e-2) {
+		t.Fatalf("rotation left the tensor diagonal: %v", diag)
+	}
+	moments := sortedMoments(props.PrincipalMoments())
+	if !moments.ApproxEqualThreshold(mgl32.Vec3{1, 2, 3}, 1e-4) {
+		t.Errorf("principal moments = %v, want [1 2 3]", moments)
+	}
+}
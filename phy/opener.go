@@ -7,3 +7,9 @@ func ReadFromStream(stream io.Reader) (*Phy, error) {
 	reader := NewReader()
 	return reader.Read(stream)
 }
+
+// WriteToStream writes a definition as a little endian phy to an io.Writer stream.
+func WriteToStream(stream io.Writer, definition *Definition) error {
+	writer := NewWriter()
+	return writer.Write(stream, definition)
+}
@@ -1,23 +1,59 @@
 package phy
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"encoding/binary"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VPhysicsID is the identifier of solids stored with a compactSurfaceHeader
+const VPhysicsID = "VPHY"
 
 // Phy
 type Phy struct {
 	// Header
 	Header header
 	// CompactSurfaces
+	// Only Size is set for solids using the legacy layout, see CompactSurface.Legacy
 	CompactSurfaces []compactSurfaceHeader
 	// LegacySurfaces
 	LegacySurfaces []legacySurfaceHeader
+	// Surfaces
+	// Decoded collision geometry, one per solid
+	Surfaces []CompactSurface
 	// Text
+	// Raw key values text section
 	Text string
+	// TextErr
+	// Error from parsing Text, nil if it parsed cleanly. The fields decoded from the
+	// text section are left empty when it is set
+	TextErr error
+	// Solids
+	// "solid" blocks of the text section
+	Solids []Solid
+	// RagdollConstraints
+	// "ragdollconstraint" blocks of the text section
+	RagdollConstraints []RagdollConstraint
+	// CollisionRules
+	// "collisionrules" block of the text section, nil if absent
+	CollisionRules *CollisionRules
+	// EditParams
+	// "editparams" block of the text section, nil if absent
+	EditParams *EditParams
+	// Breaks
+	// "break" blocks of the text section
+	Breaks []Break
 	// TriangleFaceHeaders
+	// Deprecated: only covers the first ledge of each solid. Use Surfaces
 	TriangleFaceHeaders []triangleFaceHeader
 	// TriangleFaces
+	// Deprecated: only covers the first ledge of each solid. Use Surfaces
 	TriangleFaces []triangleFace
 	// Vertices
+	// Deprecated: only covers the first ledge of each solid. Use Surfaces
 	Vertices []mgl32.Vec4
+	// ByteOrder is the byte order the file was stored in.
+	// binary.BigEndian for Xbox 360 and PS3 (.360.phy) files
+	ByteOrder binary.ByteOrder
 }
 
 // header
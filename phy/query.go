@@ -0,0 +1,192 @@
+package phy
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// planeEpsilon is the tolerance used when classifying points against hull planes
+const planeEpsilon = 1e-4
+
+// Plane is a plane of a convex hull. Points with Normal·p > Distance are outside.
+type Plane struct {
+	// Normal
+	Normal mgl32.Vec3
+	// Distance
+	Distance float32
+}
+
+// ConvexHull is a hull prepared for queries
+type ConvexHull struct {
+	// Hull
+	Hull Hull
+	// Planes
+	// Outward facing face planes
+	Planes []Plane
+	// Min
+	// Minimum corner of the bounding box
+	Min mgl32.Vec3
+	// Max
+	// Maximum corner of the bounding box
+	Max mgl32.Vec3
+}
+
+// RayHit is the result of a trace against a convex hull
+type RayHit struct {
+	// Fraction
+	// Position of the hit along the trace, from 0 at the start to 1 at the end
+	Fraction float32
+	// Normal
+	// Surface normal at the hit. Zero if the trace started inside the hull
+	Normal mgl32.Vec3
+	// StartSolid
+	// Set if the trace started inside the hull
+	StartSolid bool
+}
+
+// NewConvexHull computes the planes and bounds of a hull
+func NewConvexHull(hull Hull) *ConvexHull {
+	convex := &ConvexHull{
+		Hull:   hull,
+		Planes: make([]Plane, 0, len(hull.Triangles)),
+	}
+	if len(hull.Vertices) == 0 {
+		return convex
+	}
+
+	convex.Min = hull.Vertices[0]
+	convex.Max = hull.Vertices[0]
+	var center mgl32.Vec3
+	for _, v := range hull.Vertices {
+		for k := 0; k < 3; k++ {
+			convex.Min[k] = float32(math.Min(float64(convex.Min[k]), float64(v[k])))
+			convex.Max[k] = float32(math.Max(float64(convex.Max[k]), float64(v[k])))
+		}
+		center = center.Add(v)
+	}
+	center = center.Mul(1 / float32(len(hull.Vertices)))
+
+	for _, triangle := range hull.Triangles {
+		if int(triangle[0]) >= len(hull.Vertices) || int(triangle[1]) >= len(hull.Vertices) || int(triangle[2]) >= len(hull.Vertices) {
+			continue
+		}
+		a := hull.Vertices[triangle[0]]
+		normal := hull.Vertices[triangle[1]].Sub(a).Cross(hull.Vertices[triangle[2]].Sub(a))
+		if normal.Len() < planeEpsilon*planeEpsilon {
+			continue
+		}
+		normal = normal.Normalize()
+		// Winding is not guaranteed, so orient every plane away from the hull's centre
+		if normal.Dot(center.Sub(a)) > 0 {
+			normal = normal.Mul(-1)
+		}
+		convex.Planes = append(convex.Planes, Plane{
+			Normal:   normal,
+			Distance: normal.Dot(a),
+		})
+	}
+
+	return convex
+}
+
+// Contains returns whether a point is inside or on the hull
+func (hull *ConvexHull) Contains(point mgl32.Vec3) bool {
+	if len(hull.Planes) == 0 {
+		return false
+	}
+	for _, plane := range hull.Planes {
+		if plane.Normal.Dot(point)-plane.Distance > planeEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// OverlapsAABB returns whether the hull overlaps an axis aligned box.
+// Only the box and hull face axes are tested, so a box passing close to a hull edge
+// may be reported as overlapping.
+func (hull *ConvexHull) OverlapsAABB(min mgl32.Vec3, max mgl32.Vec3) bool {
+	if len(hull.Planes) == 0 {
+		return false
+	}
+	for k := 0; k < 3; k++ {
+		if hull.Max[k] < min[k] || hull.Min[k] > max[k] {
+			return false
+		}
+	}
+
+	center := min.Add(max).Mul(0.5)
+	extents := max.Sub(min).Mul(0.5)
+	for _, plane := range hull.Planes {
+		// Distance from the plane to the box corner nearest to the hull
+		radius := extents[0]*float32(math.Abs(float64(plane.Normal[0]))) +
+			extents[1]*float32(math.Abs(float64(plane.Normal[1]))) +
+			extents[2]*float32(math.Abs(float64(plane.Normal[2])))
+		if plane.Normal.Dot(center)-radius > plane.Distance+planeEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// Trace traces a segment from start to end against the hull
+func (hull *ConvexHull) Trace(start mgl32.Vec3, end mgl32.Vec3) (RayHit, bool) {
+	return hull.trace(start, end, 0)
+}
+
+// SweepSphere traces a sphere from start to end against the hull.
+// The hull's planes are pushed out by the radius, so hits near edges and corners are
+// slightly conservative.
+func (hull *ConvexHull) SweepSphere(start mgl32.Vec3, end mgl32.Vec3, radius float32) (RayHit, bool) {
+	return hull.trace(start, end, radius)
+}
+
+// trace clips a segment against the hull planes, each pushed out by inflate
+func (hull *ConvexHull) trace(start mgl32.Vec3, end mgl32.Vec3, inflate float32) (RayHit, bool) {
+	if len(hull.Planes) == 0 {
+		return RayHit{}, false
+	}
+
+	delta := end.Sub(start)
+	enter := float32(-math.MaxFloat32)
+	exit := float32(math.MaxFloat32)
+	var normal mgl32.Vec3
+
+	for _, plane := range hull.Planes {
+		distance := plane.Normal.Dot(start) - (plane.Distance + inflate)
+		denominator := plane.Normal.Dot(delta)
+		if float32(math.Abs(float64(denominator))) < planeEpsilon*planeEpsilon {
+			// Parallel to the plane; outside means the segment never enters
+			if distance > planeEpsilon {
+				return RayHit{}, false
+			}
+			continue
+		}
+
+		t := -distance / denominator
+		if denominator < 0 {
+			if t > enter {
+				enter = t
+				normal = plane.Normal
+			}
+		} else if t < exit {
+			exit = t
+		}
+		if enter > exit {
+			return RayHit{}, false
+		}
+	}
+
+	if exit < 0 || enter > 1 {
+		return RayHit{}, false
+	}
+	if enter < 0 {
+		return RayHit{StartSolid: true}, true
+	}
+
+	return RayHit{
+		Fraction: enter,
+		Normal:   normal,
+	}, true
+}
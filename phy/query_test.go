@@ -0,0 +1,132 @@
+package phy
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestConvexHullPlanes(t *testing.T) {
+	hull := boxHull(mgl32.Vec3{-1, -2, -3}, mgl32.Vec3{1, 2, 3})
+	// Winding should not matter
+	flipped := boxHull(mgl32.Vec3{-1, -2, -3}, mgl32.Vec3{1, 2, 3})
+	for i, triangle := range flipped.Triangles {
+		flipped.Triangles[i] = [3]uint16{triangle[0], triangle[2], triangle[1]}
+	}
+
+	for _, convex := range []*ConvexHull{NewConvexHull(hull), NewConvexHull(flipped)} {
+		if convex.Min != (mgl32.Vec3{-1, -2, -3}) || convex.Max != (mgl32.Vec3{1, 2, 3}) {
+			t.Errorf("bounds = %v %v", convex.Min, convex.Max)
+		}
+		if len(convex.Planes) != 12 {
+			t.Fatalf("got %d planes, want 12", len(convex.Planes))
+		}
+		for i, plane := range convex.Planes {
+			// boxHull lists the z, y then x faces, which are 3, 2 and 1 units out
+			want := float32(3 - i/4)
+			if !approxEqual(plane.Distance, want, 1e-5) {
+				t.Errorf("plane %d distance = %v, want %v", i, plane.Distance, want)
+			}
+		}
+	}
+
+	if empty := NewConvexHull(Hull{}); empty.Contains(mgl32.Vec3{}) || empty.OverlapsAABB(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}) {
+		t.Error("an empty hull should contain and overlap nothing")
+	}
+}
+
+func TestConvexHullContains(t *testing.T) {
+	convex := NewConvexHull(boxHull(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}))
+
+	cases := []struct {
+		name  string
+		point mgl32.Vec3
+		want  bool
+	}{
+		{"centre", mgl32.Vec3{}, true},
+		{"on face", mgl32.Vec3{1, 0, 0}, true},
+		{"on corner", mgl32.Vec3{1, 1, 1}, true},
+		{"outside face", mgl32.Vec3{0, 0, 1.01}, false},
+		{"outside corner", mgl32.Vec3{1.01, 1.01, 0}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := convex.Contains(tc.point); got != tc.want {
+				t.Errorf("Contains(%v) = %v, want %v", tc.point, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConvexHullOverlapsAABB(t *testing.T) {
+	// A tetrahedron with its slanted face cutting the corner of the unit cube
+	convex := NewConvexHull(Hull{
+		Vertices:  []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Triangles: [][3]uint16{{0, 2, 1}, {0, 1, 3}, {0, 3, 2}, {1, 2, 3}},
+	})
+
+	cases := []struct {
+		name     string
+		min, max mgl32.Vec3
+		want     bool
+	}{
+		{"contains hull", mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{2, 2, 2}, true},
+		{"inside hull", mgl32.Vec3{0.1, 0.1, 0.1}, mgl32.Vec3{0.2, 0.2, 0.2}, true},
+		{"touching face", mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{0, 1, 1}, true},
+		{"separated on an axis", mgl32.Vec3{1.5, 0, 0}, mgl32.Vec3{2, 1, 1}, false},
+		{"separated by the slanted face", mgl32.Vec3{0.6, 0.6, 0.6}, mgl32.Vec3{1, 1, 1}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := convex.OverlapsAABB(tc.min, tc.max); got != tc.want {
+				t.Errorf("OverlapsAABB(%v, %v) = %v, want %v", tc.min, tc.max, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConvexHullTrace(t *testing.T) {
+	convex := NewConvexHull(boxHull(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}))
+
+	cases := []struct {
+		name       string
+		start, end mgl32.Vec3
+		radius     float32
+		hit        bool
+		fraction   float32
+		normal     mgl32.Vec3
+		startSolid bool
+	}{
+		{"hits face", mgl32.Vec3{-3, 0, 0}, mgl32.Vec3{3, 0, 0}, 0, true, 1.0 / 3, mgl32.Vec3{-1, 0, 0}, false},
+		{"hits top", mgl32.Vec3{0.5, 0.5, 5}, mgl32.Vec3{0.5, 0.5, -5}, 0, true, 0.4, mgl32.Vec3{0, 0, 1}, false},
+		{"stops short", mgl32.Vec3{-3, 0, 0}, mgl32.Vec3{-2, 0, 0}, 0, false, 0, mgl32.Vec3{}, false},
+		{"misses", mgl32.Vec3{-3, 2, 0}, mgl32.Vec3{3, 2, 0}, 0, false, 0, mgl32.Vec3{}, false},
+		{"parallel outside", mgl32.Vec3{-3, 1.5, 0}, mgl32.Vec3{3, 1.5, 0}, 0, false, 0, mgl32.Vec3{}, false},
+		{"points away", mgl32.Vec3{-3, 0, 0}, mgl32.Vec3{-6, 0, 0}, 0, false, 0, mgl32.Vec3{}, false},
+		{"starts inside", mgl32.Vec3{}, mgl32.Vec3{3, 0, 0}, 0, true, 0, mgl32.Vec3{}, true},
+		{"sphere hits earlier", mgl32.Vec3{-3, 0, 0}, mgl32.Vec3{3, 0, 0}, 0.5, true, 0.25, mgl32.Vec3{-1, 0, 0}, false},
+		{"sphere grazes", mgl32.Vec3{-3, 1.4, 0}, mgl32.Vec3{3, 1.4, 0}, 0.5, true, 0.25, mgl32.Vec3{-1, 0, 0}, false},
+		{"sphere misses", mgl32.Vec3{-3, 1.6, 0}, mgl32.Vec3{3, 1.6, 0}, 0.5, false, 0, mgl32.Vec3{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var hit RayHit
+			var ok bool
+			if tc.radius == 0 {
+				hit, ok = convex.Trace(tc.start, tc.end)
+			} else {
+				hit, ok = convex.SweepSphere(tc.start, tc.end, tc.radius)
+			}
+			if ok != tc.hit {
+				t.Fatalf("hit = %v, want %v (%+v)", ok, tc.hit, hit)
+			}
+			if !ok {
+				return
+			}
+			if hit.StartSolid != tc.startSolid || !approxEqual(hit.Fraction, tc.fraction, 1e-5) ||
+				!hit.Normal.ApproxEqualThreshold(tc.normal, 1e-5) {
+				t.Errorf("hit = %+v, want fraction %v normal %v start solid %v", hit, tc.fraction, tc.normal, tc.startSolid)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package phy
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"github.com/galaco/studiomodel/internal"
 	"github.com/go-gl/mathgl/mgl32"
 	"io"
 	"unsafe"
@@ -10,6 +12,7 @@ import (
 
 // Reader
 type Reader struct {
+	byteOrder binary.ByteOrder
 }
 
 // Read parses a stream to a Phy struct
@@ -22,6 +25,13 @@ func (reader *Reader) Read(stream io.Reader) (*Phy, error) {
 	}
 	buf = byteBuf.Bytes()
 
+	// PHY has no magic number at the start of the file, but the header size is always the same.
+	// Reading it tells us whether this is a byte swapped console file.
+	reader.byteOrder, err = internal.DetectByteOrder(buf, int32(unsafe.Sizeof(header{})))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PHY header size: %w", err)
+	}
+
 	// Read header
 	header, err := reader.readHeader(buf)
 	if err != nil {
@@ -32,7 +42,7 @@ func (reader *Reader) Read(stream io.Reader) (*Phy, error) {
 
 	//bodyparts
 	offset += int32(unsafe.Sizeof(header))
-	compacts, legacys, offsets, err := reader.readSolids(buf, offset, header.SolidCount)
+	compacts, legacys, offsets, legacyLayouts, offset, err := reader.readSolids(buf, offset, header.SolidCount)
 	if err != nil {
 		return nil, err
 	}
@@ -42,14 +52,50 @@ func (reader *Reader) Read(stream io.Reader) (*Phy, error) {
 		return nil, err
 	}
 
-	return &Phy{
+	// Walk the full compact surface of every solid
+	surfaces := make([]CompactSurface, len(offsets))
+	legacySize := int(unsafe.Sizeof(legacySurfaceHeader{}))
+	for i, ledgeOffset := range offsets {
+		surface, err := reader.readSurface(buf, ledgeOffset-legacySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compact surface for solid %d: %w", i, err)
+		}
+		surface.Legacy = legacyLayouts[i]
+		surfaces[i] = *surface
+	}
+
+	out := &Phy{
 		Header:              header,
 		CompactSurfaces:     compacts,
 		LegacySurfaces:      legacys,
+		Surfaces:            surfaces,
+		Text:                reader.readText(buf, offset),
 		TriangleFaceHeaders: faceHeaders,
 		TriangleFaces:       faces,
 		Vertices:            vertices,
-	}, nil
+		ByteOrder:           reader.byteOrder,
+	}
+
+	// Collision geometry is still usable when the text is malformed, so a bad text
+	// section is reported on the result rather than failing the read
+	if err := parseText(out); err != nil {
+		out.TextErr = fmt.Errorf("failed to parse PHY text section: %w", err)
+	}
+
+	return out, nil
+}
+
+// readText reads the key values text that follows the last solid.
+// The text is null terminated.
+func (reader *Reader) readText(buf []byte, offset int32) string {
+	if offset < 0 || int(offset) >= len(buf) {
+		return ""
+	}
+	text := buf[offset:]
+	if end := bytes.IndexByte(text, 0); end >= 0 {
+		text = text[:end]
+	}
+	return string(text)
 }
 
 // readHeader reads phy header information
@@ -57,38 +103,67 @@ func (reader *Reader) readHeader(buf []byte) (header, error) {
 	header := header{}
 	headerSize := unsafe.Sizeof(header)
 
-	err := binary.Read(bytes.NewBuffer(buf[:headerSize]), binary.LittleEndian, &header)
+	err := binary.Read(bytes.NewBuffer(buf[:headerSize]), reader.byteOrder, &header)
 
 	return header, err
 }
 
 // readSolids reads compact and legacy entries
-func (reader *Reader) readSolids(buf []byte, offset int32, num int32) ([]compactSurfaceHeader, []legacySurfaceHeader, []int, error) {
+// Also returns whether each solid uses the pre-VPHY layout, and the offset following the last solid
+func (reader *Reader) readSolids(buf []byte, offset int32, num int32) ([]compactSurfaceHeader, []legacySurfaceHeader, []int, []bool, int32, error) {
 	compacts := make([]compactSurfaceHeader, num)
 	legacys := make([]legacySurfaceHeader, num)
 	offsets := make([]int, num)
+	legacyLayouts := make([]bool, num)
 	compactSize := int32(unsafe.Sizeof(compactSurfaceHeader{}))
 	legacySize := int32(unsafe.Sizeof(legacySurfaceHeader{}))
 
 	for i := int32(0); i < num; i++ {
+		if offset < 0 || int(offset+4) > len(buf) {
+			return compacts, legacys, offsets, legacyLayouts, offset, fmt.Errorf("solid %d at offset %d exceeds buffer (size %d)", i, offset, len(buf))
+		}
+
+		// Older files store the IVP compact surface directly after the size field
+		headerSize := compactSize
+		if !isVPhysicsSolid(buf, offset) {
+			legacyLayouts[i] = true
+			compacts[i].Size = int32(reader.byteOrder.Uint32(buf[offset : offset+4]))
+			headerSize = 4
+		}
+		if int(offset+headerSize+legacySize) > len(buf) {
+			return compacts, legacys, offsets, legacyLayouts, offset, fmt.Errorf("solid %d at offset %d exceeds buffer (size %d)", i, offset, len(buf))
+		}
+
 		//compact
-		err := binary.Read(bytes.NewBuffer(buf[offset:offset+compactSize]), binary.LittleEndian, &compacts[i])
-		if err != nil {
-			return compacts, legacys, offsets, err
+		if !legacyLayouts[i] {
+			err := binary.Read(bytes.NewBuffer(buf[offset:offset+compactSize]), reader.byteOrder, &compacts[i])
+			if err != nil {
+				return compacts, legacys, offsets, legacyLayouts, offset, err
+			}
 		}
-		offsets[i] = int(offset + compactSize + legacySize)
+		offsets[i] = int(offset + headerSize + legacySize)
 
 		//legacy
-		err = binary.Read(bytes.NewBuffer(buf[offset+compactSize:offset+compactSize+legacySize]), binary.LittleEndian, &legacys[i])
+		err := binary.Read(bytes.NewBuffer(buf[offset+headerSize:offset+headerSize+legacySize]), reader.byteOrder, &legacys[i])
 		if err != nil {
-			return compacts, legacys, offsets, err
+			return compacts, legacys, offsets, legacyLayouts, offset, err
 		}
 
-		offset += compacts[i].Size + 4 // ?
-
+		// Size does not include the size field itself
+		offset += compacts[i].Size + 4
 	}
 
-	return compacts, legacys, offsets, nil
+	return compacts, legacys, offsets, legacyLayouts, offset, nil
+}
+
+// isVPhysicsSolid returns whether the solid at offset has a "VPHY" header.
+// Byte swapped files store the identifier as a swapped int.
+func isVPhysicsSolid(buf []byte, offset int32) bool {
+	if int(offset+8) > len(buf) {
+		return false
+	}
+	id := string(buf[offset+4 : offset+8])
+	return id == VPhysicsID || id == "YHPV"
 }
 
 // readTriangles
@@ -104,16 +179,22 @@ func (reader *Reader) readTriangles(buf []byte, initialOffsets []int) ([]triangl
 		offset := initialOffsets[i]
 		// Read header
 		header := triangleFaceHeader{}
-		if err := binary.Read(bytes.NewBuffer(buf[offset:offset+headerSize]), binary.LittleEndian, &header); err != nil {
+		if err := binary.Read(bytes.NewBuffer(buf[offset:offset+headerSize]), reader.byteOrder, &header); err != nil {
 			return nil, nil, nil, err
 		}
+		// The face count is a 16 bit field followed by an unused one, so on byte swapped
+		// files reading it as 32 bits picks up the wrong half
+		header.FaceCount = int32(int16(reader.byteOrder.Uint16(buf[offset+12 : offset+14])))
 		vertexDataOffset := offset + int(header.OffsetToVertices)
+		if header.FaceCount < 0 || offset+headerSize+triangleSize*int(header.FaceCount) > len(buf) {
+			return nil, nil, nil, fmt.Errorf("solid %d has invalid face count %d", i, header.FaceCount)
+		}
 
 		// Read triangles referenced in header
 		headerTriangles := make([]triangleFace, header.FaceCount)
 		if err := binary.Read(
 			bytes.NewBuffer(buf[offset+headerSize:offset+headerSize+triangleSize*len(headerTriangles)]),
-			binary.LittleEndian,
+			reader.byteOrder,
 			&headerTriangles); err != nil {
 			return nil, nil, nil, err
 		}
@@ -143,10 +224,13 @@ func (reader *Reader) readTriangles(buf []byte, initialOffsets []int) ([]triangl
 		}
 
 		// read verts
+		if vertexDataOffset < 0 || vertexDataOffset+vertexSize*(numVerts+1) > len(buf) {
+			return nil, nil, nil, fmt.Errorf("solid %d vertices at offset %d exceed buffer (size %d)", i, vertexDataOffset, len(buf))
+		}
 		triangleVertices := make([]mgl32.Vec4, numVerts+1)
 		if err := binary.Read(
 			bytes.NewBuffer(buf[vertexDataOffset:vertexDataOffset+(vertexSize*(numVerts+1))]),
-			binary.LittleEndian,
+			reader.byteOrder,
 			&triangleVertices); err != nil {
 			return nil, nil, nil, err
 		}
@@ -0,0 +1,106 @@
+package phy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildPhy writes a phy around hand built surfaces. Solids flagged legacy are stored
+// without the VPHY header, as older tools wrote them
+func buildPhy(t *testing.T, order binary.ByteOrder, legacy []bool, text string) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	write := func(value any) {
+		if err := binary.Write(buf, order, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(header{Size: 16, Id: 0, SolidCount: int32(len(legacy)), CheckSum: 99})
+
+	for i, isLegacy := range legacy {
+		surface := singleLedgeSurface(order)
+		if i > 0 {
+			surface = treeSurface(order, false)
+		}
+		if isLegacy {
+			write(int32(len(surface.buf)))
+		} else {
+			compact := compactSurfaceHeader{
+				VPhysicsID:  int32(binary.LittleEndian.Uint32([]byte(VPhysicsID))),
+				Version:     0x100,
+				SurfaceSize: int32(len(surface.buf)),
+			}
+			compact.Size = int32(binary.Size(compact)) - 4 + compact.SurfaceSize
+			if order == binary.BigEndian {
+				// Byte swapped files store the identifier as a swapped int
+				compact.VPhysicsID = int32(binary.BigEndian.Uint32([]byte("YHPV")))
+			}
+			write(compact)
+		}
+		buf.Write(surface.buf)
+	}
+
+	buf.WriteString(text)
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+func TestReadLegacySolids(t *testing.T) {
+	const text = `solid { "index" "0" "name" "root" "mass" "4" } solid { "index" "1" "name" "arm" "parent" "root" }`
+
+	cases := []struct {
+		name   string
+		order  binary.ByteOrder
+		legacy []bool
+	}{
+		{"vphy", binary.LittleEndian, []bool{false, false}},
+		{"legacy", binary.LittleEndian, []bool{true, true}},
+		{"mixed", binary.LittleEndian, []bool{true, false}},
+		{"legacy big endian", binary.BigEndian, []bool{true, true}},
+		{"mixed big endian", binary.BigEndian, []bool{false, true}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			phy, err := ReadFromStream(bytes.NewReader(buildPhy(t, tc.order, tc.legacy, text)))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if phy.Header.CheckSum != 99 || len(phy.Surfaces) != 2 {
+				t.Fatalf("checksum %d with %d surfaces", phy.Header.CheckSum, len(phy.Surfaces))
+			}
+
+			for i, surface := range phy.Surfaces {
+				if surface.Legacy != tc.legacy[i] {
+					t.Errorf("solid %d Legacy = %v, want %v", i, surface.Legacy, tc.legacy[i])
+				}
+				if wantLedges := i + 1; len(surface.Ledges) != wantLedges {
+					t.Errorf("solid %d has %d ledges, want %d", i, len(surface.Ledges), wantLedges)
+				}
+				// Legacy solids only have the size of their header filled in
+				if compact := phy.CompactSurfaces[i]; tc.legacy[i] && compact.VPhysicsID != 0 ||
+					!tc.legacy[i] && compact.SurfaceSize != surface.ByteSize {
+					t.Errorf("solid %d compact header = %+v", i, compact)
+				}
+			}
+
+			// The text section is only found when every solid's size was followed
+			if phy.TextErr != nil {
+				t.Fatalf("text error: %v", phy.TextErr)
+			}
+			if len(phy.Solids) != 2 || phy.Solids[1].Name != "arm" || phy.Solids[1].Parent != "root" {
+				t.Errorf("solids = %+v", phy.Solids)
+			}
+		})
+	}
+}
+
+func TestReadRejectsTruncatedLegacySolid(t *testing.T) {
+	buf := buildPhy(t, binary.LittleEndian, []bool{true}, "")
+	// Cut into the surface header of the only solid
+	if _, err := ReadFromStream(bytes.NewReader(buf[:16+4+20])); err == nil {
+		t.Fatal("expected an error")
+	}
+}
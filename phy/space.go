@@ -0,0 +1,85 @@
+package phy
+
+import "github.com/go-gl/mathgl/mgl32"
+
+const (
+	// MetersToInches converts IVP units (meters) to Source units (inches)
+	MetersToInches = float32(1 / 0.0254)
+	// InchesToMeters converts Source units (inches) to IVP units (meters)
+	InchesToMeters = float32(0.0254)
+)
+
+// ToSourceSpace converts an IVP position to Source units and axes
+func ToSourceSpace(v mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{v[0], v[2], -v[1]}.Mul(MetersToInches)
+}
+
+// FromSourceSpace converts a Source position to IVP units and axes
+func FromSourceSpace(v mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{v[0], -v[2], v[1]}.Mul(InchesToMeters)
+}
+
+// DirectionToSourceSpace converts an IVP direction to Source axes, without scaling
+func DirectionToSourceSpace(v mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{v[0], v[2], -v[1]}
+}
+
+// Hull is a single convex piece as a standalone indexed triangle mesh
+type Hull struct {
+	// ClientData
+	// See Ledge.ClientData
+	ClientData int32
+	// Vertices
+	Vertices []mgl32.Vec3
+	// Triangles
+	// Indices into Vertices
+	Triangles [][3]uint16
+}
+
+// Hulls returns every ledge of the surface as a hull with its own compacted vertex list
+func (surface *CompactSurface) Hulls() []Hull {
+	hulls := make([]Hull, len(surface.Ledges))
+	for i := range surface.Ledges {
+		ledge := &surface.Ledges[i]
+		remap := make(map[uint16]uint16)
+		hull := Hull{
+			ClientData: ledge.ClientData,
+			Triangles:  make([][3]uint16, 0, len(ledge.Triangles)),
+		}
+		for _, triangle := range ledge.Triangles {
+			var out [3]uint16
+			valid := true
+			for j, index := range triangle.Vertices {
+				if int(index) >= len(surface.Vertices) {
+					valid = false
+					break
+				}
+				mapped, ok := remap[index]
+				if !ok {
+					mapped = uint16(len(hull.Vertices))
+					remap[index] = mapped
+					hull.Vertices = append(hull.Vertices, surface.Vertices[index])
+				}
+				out[j] = mapped
+			}
+			if valid {
+				hull.Triangles = append(hull.Triangles, out)
+			}
+		}
+		hulls[i] = hull
+	}
+	return hulls
+}
+
+// Transform returns a copy of the hull with every vertex passed through fn
+func (hull *Hull) Transform(fn func(mgl32.Vec3) mgl32.Vec3) Hull {
+	out := Hull{
+		ClientData: hull.ClientData,
+		Vertices:   make([]mgl32.Vec3, len(hull.Vertices)),
+		Triangles:  hull.Triangles,
+	}
+	for i, v := range hull.Vertices {
+		out.Vertices[i] = fn(v)
+	}
+	return out
+}
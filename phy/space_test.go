@@ -0,0 +1,78 @@
+package phy
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSourceSpace(t *testing.T) {
+	cases := []struct {
+		name   string
+		ivp    mgl32.Vec3
+		source mgl32.Vec3
+	}{
+		{"origin", mgl32.Vec3{}, mgl32.Vec3{}},
+		{"ivp x is source x", mgl32.Vec3{0.0254, 0, 0}, mgl32.Vec3{1, 0, 0}},
+		{"ivp y is source down", mgl32.Vec3{0, 0.0254, 0}, mgl32.Vec3{0, 0, -1}},
+		{"ivp z is source y", mgl32.Vec3{0, 0, 0.0254}, mgl32.Vec3{0, 1, 0}},
+		{"mixed", mgl32.Vec3{0.254, -0.0508, 0.0762}, mgl32.Vec3{10, 3, 2}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ToSourceSpace(tc.ivp); !got.ApproxEqualThreshold(tc.source, 1e-4) {
+				t.Errorf("ToSourceSpace(%v) = %v, want %v", tc.ivp, got, tc.source)
+			}
+			if got := FromSourceSpace(tc.source); !got.ApproxEqualThreshold(tc.ivp, 1e-6) {
+				t.Errorf("FromSourceSpace(%v) = %v, want %v", tc.source, got, tc.ivp)
+			}
+			if got := FromSourceSpace(ToSourceSpace(tc.ivp)); !got.ApproxEqualThreshold(tc.ivp, 1e-6) {
+				t.Errorf("round trip of %v = %v", tc.ivp, got)
+			}
+		})
+	}
+
+	if got := DirectionToSourceSpace(mgl32.Vec3{1, 2, 3}); got != (mgl32.Vec3{1, 3, -2}) {
+		t.Errorf("DirectionToSourceSpace = %v, want the axes swapped without scaling", got)
+	}
+}
+
+func TestCompactSurfaceHulls(t *testing.T) {
+	surface := CompactSurface{
+		Vertices: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {5, 5, 5}, {1, 1, 1}},
+		Ledges: []Ledge{
+			{ClientData: 2, Triangles: []Triangle{
+				{Vertices: [3]uint16{3, 1, 0}}, {Vertices: [3]uint16{0, 1, 2}},
+				// Out of range triangles are dropped
+				{Vertices: [3]uint16{0, 2, 9}},
+			}},
+			{ClientData: 7, Triangles: []Triangle{{Vertices: [3]uint16{5, 1, 2}}}},
+		},
+	}
+
+	hulls := surface.Hulls()
+	if len(hulls) != 2 {
+		t.Fatalf("got %d hulls, want 2", len(hulls))
+	}
+
+	// Vertices are renumbered in order of first use, and unused ones are left out
+	first := hulls[0]
+	if first.ClientData != 2 || !slices.Equal(first.Triangles, [][3]uint16{{0, 1, 2}, {2, 1, 3}}) ||
+		!slices.Equal(first.Vertices, []mgl32.Vec3{{0, 0, 1}, {1, 0, 0}, {0, 0, 0}, {0, 1, 0}}) {
+		t.Errorf("first hull = %+v", first)
+	}
+	second := hulls[1]
+	if second.ClientData != 7 || !slices.Equal(second.Triangles, [][3]uint16{{0, 1, 2}}) ||
+		!slices.Equal(second.Vertices, []mgl32.Vec3{{1, 1, 1}, {1, 0, 0}, {0, 1, 0}}) {
+		t.Errorf("second hull = %+v", second)
+	}
+
+	moved := first.Transform(func(v mgl32.Vec3) mgl32.Vec3 { return v.Add(mgl32.Vec3{10, 0, 0}) })
+	if moved.ClientData != 2 || !slices.Equal(moved.Triangles, first.Triangles) || moved.Vertices[0] != (mgl32.Vec3{10, 0, 1}) {
+		t.Errorf("transformed hull = %+v", moved)
+	}
+	if first.Vertices[0] != (mgl32.Vec3{0, 0, 1}) {
+		t.Error("Transform modified the original hull")
+	}
+}
@@ -0,0 +1,334 @@
+package phy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// ivpLedgeSize is the size of IVP_Compact_Ledge
+	ivpLedgeSize = 16
+	// ivpTriangleSize is the size of IVP_Compact_Triangle
+	ivpTriangleSize = 16
+	// ivpPointSize is the size of IVP_Compact_Poly_Point
+	ivpPointSize = 16
+	// ivpLedgeTreeNodeSize is the size of IVP_Compact_Ledgetree_Node
+	ivpLedgeTreeNodeSize = 28
+	// maxLedgeTreeDepth guards against cyclic trees in corrupt files
+	maxLedgeTreeDepth = 64
+)
+
+// CompactSurface is the decoded IVP collision geometry of one solid.
+// Coordinates are in IVP space (meters, Y and Z swapped relative to Source).
+type CompactSurface struct {
+	// MassCenter
+	MassCenter mgl32.Vec3
+	// RotationInertia
+	RotationInertia mgl32.Vec3
+	// UpperLimitRadius
+	UpperLimitRadius float32
+	// MaxDeviation
+	MaxDeviation uint8
+	// ByteSize
+	// Size of the compact surface in bytes
+	ByteSize int32
+	// Vertices
+	// Vertex pool shared by every ledge
+	Vertices []mgl32.Vec3
+	// Ledges
+	// Convex pieces making up the solid
+	Ledges []Ledge
+	// LedgeTree
+	// Bounding sphere tree over Ledges. The first node is the root
+	LedgeTree []LedgeTreeNode
+	// Legacy
+	// Set for solids stored without the VPHY header, as written by older tools
+	Legacy bool
+}
+
+// Ledge is a single convex piece of a compact surface
+type Ledge struct {
+	// ClientData
+	// Set by studiomdl to the index of the solid (bone) this ledge belongs to
+	ClientData int32
+	// Triangles
+	Triangles []Triangle
+}
+
+// Triangle is a face of a ledge
+type Triangle struct {
+	// Index
+	Index uint16
+	// MaterialIndex
+	MaterialIndex uint8
+	// IsVirtual
+	IsVirtual bool
+	// Vertices
+	// Indices into CompactSurface.Vertices
+	Vertices [3]uint16
+}
+
+// LedgeTreeNode is a node of the ledge bounding tree
+type LedgeTreeNode struct {
+	// Center
+	Center mgl32.Vec3
+	// Radius
+	Radius float32
+	// BoxSizes
+	BoxSizes [3]uint8
+	// Left
+	// Index of the left child in LedgeTree, -1 for leaves
+	Left int
+	// Right
+	// Index of the right child in LedgeTree, -1 for leaves
+	Right int
+	// Ledge
+	// Index into Ledges for leaves, -1 otherwise
+	Ledge int
+}
+
+// IsLeaf returns whether this node references a ledge
+func (node *LedgeTreeNode) IsLeaf() bool {
+	return node.Ledge >= 0
+}
+
+// NumTriangles returns the number of triangles across all ledges
+func (surface *CompactSurface) NumTriangles() int {
+	num := 0
+	for i := range surface.Ledges {
+		num += len(surface.Ledges[i].Triangles)
+	}
+	return num
+}
+
+// surfaceReader walks a single IVP_Compact_Surface
+type surfaceReader struct {
+	reader *Reader
+	buf    []byte
+	start  int
+	out    *CompactSurface
+	// ledgePools records the absolute vertex pool offset of each ledge
+	ledgePools []int
+	// ledgeOffsets maps ledge offsets to their index, so shared ledges are only read once
+	ledgeOffsets map[int]int
+	// nodeOffsets records every node read. Nodes belong to exactly one parent, so a node
+	// reached twice means the tree is corrupt
+	nodeOffsets map[int]bool
+}
+
+// readSurface decodes the IVP_Compact_Surface starting at offset
+func (reader *Reader) readSurface(buf []byte, offset int) (*CompactSurface, error) {
+	legacySize := int(unsafe.Sizeof(legacySurfaceHeader{}))
+	if offset < 0 || offset+legacySize > len(buf) {
+		return nil, fmt.Errorf("compact surface at offset %d exceeds buffer (size %d)", offset, len(buf))
+	}
+
+	walker := &surfaceReader{
+		reader:       reader,
+		buf:          buf,
+		start:        offset,
+		out:          &CompactSurface{},
+		ledgeOffsets: make(map[int]int),
+		nodeOffsets:  make(map[int]bool),
+	}
+
+	walker.out.MassCenter = walker.vec3(offset)
+	walker.out.RotationInertia = walker.vec3(offset + 12)
+	walker.out.UpperLimitRadius = walker.float(offset + 24)
+	deviationAndSize := walker.uint32(offset + 28)
+	walker.out.MaxDeviation = uint8(reader.bitfield(deviationAndSize, 0, 8))
+	walker.out.ByteSize = int32(reader.bitfield(deviationAndSize, 8, 24))
+	ledgeTreeRoot := int32(walker.uint32(offset + 32))
+
+	if ledgeTreeRoot > 0 && offset+int(ledgeTreeRoot)+ivpLedgeTreeNodeSize <= len(buf) {
+		if _, err := walker.readNode(offset+int(ledgeTreeRoot), 0); err != nil {
+			return nil, err
+		}
+	} else {
+		// No tree; a single ledge follows the surface header
+		if _, err := walker.readLedge(offset + legacySize); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := walker.readVertices(); err != nil {
+		return nil, err
+	}
+
+	return walker.out, nil
+}
+
+// readNode reads a ledge tree node and its children, returning its index
+func (walker *surfaceReader) readNode(offset int, depth int) (int, error) {
+	if depth > maxLedgeTreeDepth {
+		return -1, fmt.Errorf("ledge tree deeper than %d nodes", maxLedgeTreeDepth)
+	}
+	if offset < walker.start || offset+ivpLedgeTreeNodeSize > len(walker.buf) {
+		return -1, fmt.Errorf("ledge tree node at offset %d out of bounds", offset)
+	}
+	if walker.nodeOffsets[offset] {
+		return -1, fmt.Errorf("ledge tree node at offset %d is referenced more than once", offset)
+	}
+	if len(walker.out.LedgeTree) >= len(walker.buf)/ivpLedgeTreeNodeSize {
+		return -1, fmt.Errorf("ledge tree has more nodes than fit in the buffer")
+	}
+	walker.nodeOffsets[offset] = true
+
+	rightOffset := int32(walker.uint32(offset))
+	ledgeOffset := int32(walker.uint32(offset + 4))
+	node := LedgeTreeNode{
+		Center: walker.vec3(offset + 8),
+		Radius: walker.float(offset + 20),
+		Left:   -1,
+		Right:  -1,
+		Ledge:  -1,
+	}
+	copy(node.BoxSizes[:], walker.buf[offset+24:offset+27])
+
+	index := len(walker.out.LedgeTree)
+	walker.out.LedgeTree = append(walker.out.LedgeTree, node)
+
+	if rightOffset == 0 {
+		// Leaf
+		ledge, err := walker.readLedge(offset + int(ledgeOffset))
+		if err != nil {
+			return -1, err
+		}
+		walker.out.LedgeTree[index].Ledge = ledge
+		return index, nil
+	}
+
+	// The left child always directly follows its parent
+	left, err := walker.readNode(offset+ivpLedgeTreeNodeSize, depth+1)
+	if err != nil {
+		return -1, err
+	}
+	right, err := walker.readNode(offset+int(rightOffset), depth+1)
+	if err != nil {
+		return -1, err
+	}
+	walker.out.LedgeTree[index].Left = left
+	walker.out.LedgeTree[index].Right = right
+
+	return index, nil
+}
+
+// readLedge reads an IVP_Compact_Ledge and its triangles, returning its index
+func (walker *surfaceReader) readLedge(offset int) (int, error) {
+	if index, ok := walker.ledgeOffsets[offset]; ok {
+		return index, nil
+	}
+	if offset < walker.start || offset+ivpLedgeSize > len(walker.buf) {
+		return -1, fmt.Errorf("ledge at offset %d out of bounds", offset)
+	}
+
+	pointOffset := int32(walker.uint32(offset))
+	clientData := int32(walker.uint32(offset + 4))
+	numTriangles := int(int16(walker.uint16(offset + 12)))
+	if numTriangles < 0 || offset+ivpLedgeSize+numTriangles*ivpTriangleSize > len(walker.buf) {
+		return -1, fmt.Errorf("ledge at offset %d has invalid triangle count %d", offset, numTriangles)
+	}
+
+	ledge := Ledge{
+		ClientData: clientData,
+		Triangles:  make([]Triangle, numTriangles),
+	}
+	for i := range ledge.Triangles {
+		triangleOffset := offset + ivpLedgeSize + i*ivpTriangleSize
+		word := walker.uint32(triangleOffset)
+		triangle := Triangle{
+			Index:         uint16(walker.reader.bitfield(word, 0, 12)),
+			MaterialIndex: uint8(walker.reader.bitfield(word, 24, 7)),
+			IsVirtual:     walker.reader.bitfield(word, 31, 1) != 0,
+		}
+		for j := 0; j < 3; j++ {
+			edge := walker.uint32(triangleOffset + 4 + j*4)
+			triangle.Vertices[j] = uint16(walker.reader.bitfield(edge, 0, 16))
+		}
+		ledge.Triangles[i] = triangle
+	}
+
+	index := len(walker.out.Ledges)
+	walker.out.Ledges = append(walker.out.Ledges, ledge)
+	walker.ledgePools = append(walker.ledgePools, offset+int(pointOffset))
+	walker.ledgeOffsets[offset] = index
+
+	return index, nil
+}
+
+// readVertices reads the vertex pool referenced by all ledges and rebases triangle indices onto it
+func (walker *surfaceReader) readVertices() error {
+	if len(walker.out.Ledges) == 0 {
+		return nil
+	}
+
+	base := walker.ledgePools[0]
+	for _, pool := range walker.ledgePools {
+		if pool < base {
+			base = pool
+		}
+	}
+
+	numVertices := 0
+	for i := range walker.out.Ledges {
+		shift := (walker.ledgePools[i] - base) / ivpPointSize
+		for j := range walker.out.Ledges[i].Triangles {
+			triangle := &walker.out.Ledges[i].Triangles[j]
+			for k := range triangle.Vertices {
+				index := int(triangle.Vertices[k]) + shift
+				if index > math.MaxUint16 {
+					return fmt.Errorf("ledge %d vertex index %d exceeds pool limits", i, index)
+				}
+				triangle.Vertices[k] = uint16(index)
+				if index+1 > numVertices {
+					numVertices = index + 1
+				}
+			}
+		}
+	}
+
+	if base < 0 || base+numVertices*ivpPointSize > len(walker.buf) {
+		return fmt.Errorf("vertex pool at offset %d with %d vertices exceeds buffer", base, numVertices)
+	}
+
+	walker.out.Vertices = make([]mgl32.Vec3, numVertices)
+	for i := range walker.out.Vertices {
+		// The 4th component of a poly point is unused padding (hesse value)
+		walker.out.Vertices[i] = walker.vec3(base + i*ivpPointSize)
+	}
+
+	return nil
+}
+
+// uint32 reads a 32 bit word
+func (walker *surfaceReader) uint32(offset int) uint32 {
+	return walker.reader.byteOrder.Uint32(walker.buf[offset : offset+4])
+}
+
+// uint16 reads a 16 bit word
+func (walker *surfaceReader) uint16(offset int) uint16 {
+	return walker.reader.byteOrder.Uint16(walker.buf[offset : offset+2])
+}
+
+// float reads a float
+func (walker *surfaceReader) float(offset int) float32 {
+	return math.Float32frombits(walker.uint32(offset))
+}
+
+// vec3 reads 3 consecutive floats
+func (walker *surfaceReader) vec3(offset int) mgl32.Vec3 {
+	return mgl32.Vec3{walker.float(offset), walker.float(offset + 4), walker.float(offset + 8)}
+}
+
+// bitfield extracts a C bitfield from a word. Compilers for big-endian consoles allocate
+// bitfields from the most significant bit, so the position is mirrored for those files.
+func (reader *Reader) bitfield(word uint32, shift uint, width uint) uint32 {
+	if reader.byteOrder == binary.BigEndian {
+		shift = 32 - shift - width
+	}
+	return (word >> shift) & (1<<width - 1)
+}
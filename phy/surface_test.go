@@ -0,0 +1,316 @@
+package phy
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// tetrahedron is a closed 4 triangle ledge over local vertices 0-3
+var tetrahedron = [][3]uint16{{0, 2, 1}, {0, 1, 3}, {0, 3, 2}, {1, 2, 3}}
+
+// surfaceFixture lays out an IVP compact surface by hand
+type surfaceFixture struct {
+	writer *Writer
+	buf    []byte
+}
+
+func newSurfaceFixture(order binary.ByteOrder, size int) *surfaceFixture {
+	return &surfaceFixture{writer: &Writer{ByteOrder: order}, buf: make([]byte, size)}
+}
+
+func (fixture *surfaceFixture) putUint32(offset int, value uint32) {
+	fixture.writer.ByteOrder.PutUint32(fixture.buf[offset:], value)
+}
+
+func (fixture *surfaceFixture) putInt32(offset int, value int32) {
+	fixture.putUint32(offset, uint32(value))
+}
+
+func (fixture *surfaceFixture) putVec3(offset int, v mgl32.Vec3) {
+	for i := range v {
+		fixture.putUint32(offset+i*4, math.Float32bits(v[i]))
+	}
+}
+
+// header writes the surface header with a ledge tree root offset
+func (fixture *surfaceFixture) header(root int32) {
+	fixture.putVec3(0, mgl32.Vec3{0.25, 0.5, 0.75})
+	fixture.putVec3(12, mgl32.Vec3{1, 2, 3})
+	fixture.putUint32(24, math.Float32bits(2))
+	fixture.putUint32(28, fixture.writer.bitfield(3, 0, 8)|fixture.writer.bitfield(uint32(len(fixture.buf)), 8, 24))
+	fixture.putUint32(32, uint32(root))
+}
+
+// ledge writes a tetrahedron ledge whose vertex pool is pool bytes after it
+func (fixture *surfaceFixture) ledge(offset int, pool int, clientData int32, material uint32) {
+	fixture.putUint32(offset, uint32(pool))
+	fixture.putUint32(offset+4, uint32(clientData))
+	fixture.writer.ByteOrder.PutUint16(fixture.buf[offset+12:], uint16(len(tetrahedron)))
+	for i, triangle := range tetrahedron {
+		triangleOffset := offset + ivpLedgeSize + i*ivpTriangleSize
+		word := fixture.writer.bitfield(uint32(i), 0, 12) | fixture.writer.bitfield(material, 24, 7)
+		if i == 3 {
+			word |= fixture.writer.bitfield(1, 31, 1)
+		}
+		fixture.putUint32(triangleOffset, word)
+		for j, index := range triangle {
+			fixture.putUint32(triangleOffset+4+j*4, fixture.writer.bitfield(uint32(index), 0, 16))
+		}
+	}
+}
+
+// points writes a tetrahedron's vertex pool, scaled and moved along x
+func (fixture *surfaceFixture) points(offset int, x float32) {
+	for i, v := range []mgl32.Vec3{{x, 0, 0}, {x + 1, 0, 0}, {x, 1, 0}, {x, 0, 1}} {
+		fixture.putVec3(offset+i*ivpPointSize, v)
+	}
+}
+
+// node writes a ledge tree node. Leaves have a right offset of 0
+func (fixture *surfaceFixture) node(offset int, right int32, ledge int32, center mgl32.Vec3) {
+	fixture.putInt32(offset, right)
+	fixture.putInt32(offset+4, ledge)
+	fixture.putVec3(offset+8, center)
+	fixture.putUint32(offset+20, math.Float32bits(1))
+	copy(fixture.buf[offset+24:], []byte{1, 2, 3})
+}
+
+const (
+	fixtureHeaderSize = 48
+	fixtureLedgeSize  = ivpLedgeSize + 4*ivpTriangleSize
+	fixturePoolSize   = 4 * ivpPointSize
+)
+
+// singleLedgeSurface has no ledge tree, just one ledge after the header
+func singleLedgeSurface(order binary.ByteOrder) *surfaceFixture {
+	fixture := newSurfaceFixture(order, fixtureHeaderSize+fixtureLedgeSize+fixturePoolSize)
+	fixture.header(0)
+	fixture.ledge(fixtureHeaderSize, fixtureLedgeSize, 7, 5)
+	fixture.points(fixtureHeaderSize+fixtureLedgeSize, 0)
+	return fixture
+}
+
+// treeSurface has two ledges with their own vertex pools under a 3 node tree.
+// When shared is set both leaves reference the first ledge
+func treeSurface(order binary.ByteOrder, shared bool) *surfaceFixture {
+	ledgeA := fixtureHeaderSize
+	ledgeB := ledgeA + fixtureLedgeSize
+	poolA := ledgeB + fixtureLedgeSize
+	poolB := poolA + fixturePoolSize
+	root := poolB + fixturePoolSize
+	left := root + ivpLedgeTreeNodeSize
+	right := left + ivpLedgeTreeNodeSize
+
+	fixture := newSurfaceFixture(order, right+ivpLedgeTreeNodeSize)
+	fixture.header(int32(root))
+	fixture.ledge(ledgeA, poolA-ledgeA, 0, 1)
+	fixture.ledge(ledgeB, poolB-ledgeB, 1, 2)
+	fixture.points(poolA, 0)
+	fixture.points(poolB, 10)
+	fixture.node(root, int32(right-root), 0, mgl32.Vec3{5, 0, 0})
+	fixture.node(left, 0, int32(ledgeA-left), mgl32.Vec3{0, 0, 0})
+	rightLedge := ledgeB
+	if shared {
+		rightLedge = ledgeA
+	}
+	fixture.node(right, 0, int32(rightLedge-right), mgl32.Vec3{10, 0, 0})
+	return fixture
+}
+
+func readFixture(fixture *surfaceFixture) (*CompactSurface, error) {
+	reader := &Reader{byteOrder: fixture.writer.ByteOrder}
+	return reader.readSurface(fixture.buf, 0)
+}
+
+func TestReadSurface(t *testing.T) {
+	orders := []struct {
+		name  string
+		order binary.ByteOrder
+	}{
+		{"little endian", binary.LittleEndian},
+		{"big endian", binary.BigEndian},
+	}
+	cases := []struct {
+		name         string
+		build        func(binary.ByteOrder) *surfaceFixture
+		ledges       int
+		vertices     int
+		tree         []LedgeTreeNode
+		clientData   []int32
+		materials    []uint8
+		firstOfLedge []mgl32.Vec3
+	}{
+		{
+			name:         "single ledge",
+			build:        singleLedgeSurface,
+			ledges:       1,
+			vertices:     4,
+			clientData:   []int32{7},
+			materials:    []uint8{5},
+			firstOfLedge: []mgl32.Vec3{{0, 0, 0}},
+		},
+		{
+			name:     "ledge tree",
+			build:    func(order binary.ByteOrder) *surfaceFixture { return treeSurface(order, false) },
+			ledges:   2,
+			vertices: 8,
+			tree: []LedgeTreeNode{
+				{Left: 1, Right: 2, Ledge: -1},
+				{Left: -1, Right: -1, Ledge: 0},
+				{Left: -1, Right: -1, Ledge: 1},
+			},
+			clientData:   []int32{0, 1},
+			materials:    []uint8{1, 2},
+			firstOfLedge: []mgl32.Vec3{{0, 0, 0}, {10, 0, 0}},
+		},
+		{
+			name:     "shared ledge",
+			build:    func(order binary.ByteOrder) *surfaceFixture { return treeSurface(order, true) },
+			ledges:   1,
+			vertices: 4,
+			tree: []LedgeTreeNode{
+				{Left: 1, Right: 2, Ledge: -1},
+				{Left: -1, Right: -1, Ledge: 0},
+				{Left: -1, Right: -1, Ledge: 0},
+			},
+			clientData:   []int32{0},
+			materials:    []uint8{1},
+			firstOfLedge: []mgl32.Vec3{{0, 0, 0}},
+		},
+	}
+
+	for _, order := range orders {
+		for _, tc := range cases {
+			t.Run(order.name+"/"+tc.name, func(t *testing.T) {
+				fixture := tc.build(order.order)
+				surface, err := readFixture(fixture)
+				if err != nil {
+					t.Fatalf("read failed: %v", err)
+				}
+
+				if surface.MassCenter != (mgl32.Vec3{0.25, 0.5, 0.75}) || surface.RotationInertia != (mgl32.Vec3{1, 2, 3}) ||
+					surface.UpperLimitRadius != 2 || surface.MaxDeviation != 3 || int(surface.ByteSize) != len(fixture.buf) {
+					t.Errorf("header = %v %v %v %d %d", surface.MassCenter, surface.RotationInertia,
+						surface.UpperLimitRadius, surface.MaxDeviation, surface.ByteSize)
+				}
+				if len(surface.Ledges) != tc.ledges || len(surface.Vertices) != tc.vertices {
+					t.Fatalf("read %d ledges over %d vertices, want %d over %d", len(surface.Ledges), len(surface.Vertices), tc.ledges, tc.vertices)
+				}
+				if surface.NumTriangles() != tc.ledges*len(tetrahedron) {
+					t.Errorf("NumTriangles = %d", surface.NumTriangles())
+				}
+
+				if len(surface.LedgeTree) != len(tc.tree) {
+					t.Fatalf("read %d tree nodes, want %d", len(surface.LedgeTree), len(tc.tree))
+				}
+				for i, want := range tc.tree {
+					got := surface.LedgeTree[i]
+					if got.Left != want.Left || got.Right != want.Right || got.Ledge != want.Ledge || got.IsLeaf() != (want.Ledge >= 0) {
+						t.Errorf("node %d = %+v, want %+v", i, got, want)
+					}
+					if got.Radius != 1 || got.BoxSizes != [3]uint8{1, 2, 3} {
+						t.Errorf("node %d radius %v box sizes %v", i, got.Radius, got.BoxSizes)
+					}
+				}
+
+				for i, ledge := range surface.Ledges {
+					if ledge.ClientData != tc.clientData[i] {
+						t.Errorf("ledge %d client data = %d, want %d", i, ledge.ClientData, tc.clientData[i])
+					}
+					for j, triangle := range ledge.Triangles {
+						if int(triangle.Index) != j || triangle.MaterialIndex != tc.materials[i] || triangle.IsVirtual != (j == 3) {
+							t.Errorf("ledge %d triangle %d = %+v", i, j, triangle)
+						}
+						// Pools are rebased onto one shared vertex list
+						for k, index := range triangle.Vertices {
+							if want := tetrahedron[j][k] + uint16(i*4); index != want {
+								t.Errorf("ledge %d triangle %d vertex %d = %d, want %d", i, j, k, index, want)
+							}
+						}
+					}
+					if first := surface.Vertices[i*4]; first != tc.firstOfLedge[i] {
+						t.Errorf("ledge %d first vertex = %v, want %v", i, first, tc.firstOfLedge[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestReadSurfaceRejectsCorruptData(t *testing.T) {
+	cases := []struct {
+		name  string
+		build func() *surfaceFixture
+	}{
+		{"truncated header", func() *surfaceFixture {
+			fixture := singleLedgeSurface(binary.LittleEndian)
+			fixture.buf = fixture.buf[:40]
+			return fixture
+		}},
+		{"negative triangle count", func() *surfaceFixture {
+			fixture := singleLedgeSurface(binary.LittleEndian)
+			binary.LittleEndian.PutUint16(fixture.buf[fixtureHeaderSize+12:], 0x8000)
+			return fixture
+		}},
+		{"triangles past end", func() *surfaceFixture {
+			fixture := singleLedgeSurface(binary.LittleEndian)
+			binary.LittleEndian.PutUint16(fixture.buf[fixtureHeaderSize+12:], 100)
+			return fixture
+		}},
+		{"vertex pool past end", func() *surfaceFixture {
+			fixture := singleLedgeSurface(binary.LittleEndian)
+			fixture.buf = fixture.buf[:len(fixture.buf)-ivpPointSize]
+			return fixture
+		}},
+		{"ledge before surface", func() *surfaceFixture {
+			fixture := treeSurface(binary.LittleEndian, false)
+			left := len(fixture.buf) - 2*ivpLedgeTreeNodeSize
+			fixture.putInt32(left+4, int32(-left-16))
+			return fixture
+		}},
+		{"back pointer", func() *surfaceFixture {
+			fixture := treeSurface(binary.LittleEndian, false)
+			// Turn the left leaf into a node whose right child is the root
+			left := len(fixture.buf) - 2*ivpLedgeTreeNodeSize
+			fixture.putInt32(left, -ivpLedgeTreeNodeSize)
+			return fixture
+		}},
+		{"shared child", func() *surfaceFixture {
+			fixture := treeSurface(binary.LittleEndian, false)
+			// Both children of the root are the node that follows it
+			root := len(fixture.buf) - 3*ivpLedgeTreeNodeSize
+			fixture.putInt32(root, ivpLedgeTreeNodeSize)
+			return fixture
+		}},
+		{"shared child chain", func() *surfaceFixture {
+			// Every node's children are both the next node, which is walked 2^n times
+			// unless repeated nodes are rejected
+			const depth = 40
+			fixture := newSurfaceFixture(binary.LittleEndian, fixtureHeaderSize+fixtureLedgeSize+fixturePoolSize+(depth+1)*ivpLedgeTreeNodeSize)
+			ledge := fixtureHeaderSize
+			pool := ledge + fixtureLedgeSize
+			root := pool + fixturePoolSize
+			fixture.header(int32(root))
+			fixture.ledge(ledge, pool-ledge, 0, 1)
+			fixture.points(pool, 0)
+			for i := 0; i < depth; i++ {
+				fixture.node(root+i*ivpLedgeTreeNodeSize, ivpLedgeTreeNodeSize, 0, mgl32.Vec3{})
+			}
+			leaf := root + depth*ivpLedgeTreeNodeSize
+			fixture.node(leaf, 0, int32(ledge-leaf), mgl32.Vec3{})
+			return fixture
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			surface, err := readFixture(tc.build())
+			if err == nil {
+				t.Fatalf("expected an error, read %d tree nodes", len(surface.LedgeTree))
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package phy
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/galaco/studiomodel/internal"
+)
+
+// Solid is a "solid" block from the text section, describing one collision solid
+type Solid struct {
+	// Index
+	// Index of the solid in the binary section
+	Index int
+	// Name
+	// Name of the bone this solid is attached to
+	Name string
+	// Parent
+	// Name of the parent bone
+	Parent string
+	// Mass
+	Mass float32
+	// SurfaceProp
+	SurfaceProp string
+	// Damping
+	Damping float32
+	// RotDamping
+	RotDamping float32
+	// Drag
+	Drag float32
+	// Inertia
+	Inertia float32
+	// Volume
+	Volume float32
+	// Values holds every key of the block, lowercased
+	Values map[string]string
+}
+
+// RagdollConstraint is a "ragdollconstraint" block, limiting rotation of a child solid relative to its parent
+type RagdollConstraint struct {
+	// Parent
+	// Parent solid index
+	Parent int
+	// Child
+	// Child solid index
+	Child int
+	// XMin
+	XMin float32
+	// XMax
+	XMax float32
+	// XFriction
+	XFriction float32
+	// YMin
+	YMin float32
+	// YMax
+	YMax float32
+	// YFriction
+	YFriction float32
+	// ZMin
+	ZMin float32
+	// ZMax
+	ZMax float32
+	// ZFriction
+	ZFriction float32
+	// Values holds every key of the block, lowercased
+	Values map[string]string
+}
+
+// CollisionRules is the "collisionrules" block
+type CollisionRules struct {
+	// SelfCollisions
+	// When false no solids of the model collide with each other
+	SelfCollisions bool
+	// CollisionPairs
+	// Pairs of solid indices that are allowed to collide
+	CollisionPairs [][2]int
+}
+
+// EditParams is the "editparams" block, recording the studiomdl $collisionjoints settings
+type EditParams struct {
+	// RootName
+	RootName string
+	// TotalMass
+	TotalMass float32
+	// Concave
+	Concave bool
+	// JointMerge
+	// Pairs of bone names merged into one solid
+	JointMerge [][2]string
+	// Values holds every key of the block, lowercased
+	Values map[string]string
+}
+
+// Break is a "break" block, describing a gib spawned when the model breaks
+type Break struct {
+	// Model
+	Model string
+	// Ragdoll
+	Ragdoll string
+	// Health
+	Health float32
+	// FadeTime
+	FadeTime float32
+	// FadeMinDist
+	FadeMinDist float32
+	// FadeMaxDist
+	FadeMaxDist float32
+	// Burst
+	Burst float32
+	// Debris
+	Debris bool
+	// Values holds every key of the block, lowercased
+	Values map[string]string
+}
+
+// parseText parses the key values text section into its typed blocks
+func parseText(phy *Phy) error {
+	nodes, err := internal.ParseKeyValuesRaw(phy.Text)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if !node.IsBlock {
+			continue
+		}
+		switch strings.ToLower(node.Key) {
+		case "solid":
+			phy.Solids = append(phy.Solids, Solid{
+				Index:       node.Int("index", 0),
+				Name:        stringValue(node, "name"),
+				Parent:      stringValue(node, "parent"),
+				Mass:        node.Float("mass", 0),
+				SurfaceProp: stringValue(node, "surfaceprop"),
+				Damping:     node.Float("damping", 0),
+				RotDamping:  node.Float("rotdamping", 0),
+				Drag:        node.Float("drag", 0),
+				Inertia:     node.Float("inertia", 0),
+				Volume:      node.Float("volume", 0),
+				Values:      node.Values(),
+			})
+		case "ragdollconstraint":
+			phy.RagdollConstraints = append(phy.RagdollConstraints, RagdollConstraint{
+				Parent:    node.Int("parent", -1),
+				Child:     node.Int("child", -1),
+				XMin:      node.Float("xmin", 0),
+				XMax:      node.Float("xmax", 0),
+				XFriction: node.Float("xfriction", 0),
+				YMin:      node.Float("ymin", 0),
+				YMax:      node.Float("ymax", 0),
+				YFriction: node.Float("yfriction", 0),
+				ZMin:      node.Float("zmin", 0),
+				ZMax:      node.Float("zmax", 0),
+				ZFriction: node.Float("zfriction", 0),
+				Values:    node.Values(),
+			})
+		case "collisionrules":
+			rules := &CollisionRules{
+				SelfCollisions: node.Bool("selfcollisions", true),
+			}
+			for _, pair := range node.FindAll("collisionpair") {
+				a, b, ok := splitPair(pair.Value)
+				if !ok {
+					continue
+				}
+				i, errA := strconv.Atoi(a)
+				j, errB := strconv.Atoi(b)
+				if errA == nil && errB == nil {
+					rules.CollisionPairs = append(rules.CollisionPairs, [2]int{i, j})
+				}
+			}
+			phy.CollisionRules = rules
+		case "editparams":
+			params := &EditParams{
+				RootName:  stringValue(node, "rootname"),
+				TotalMass: node.Float("totalmass", 0),
+				Concave:   node.Bool("concave", false),
+				Values:    node.Values(),
+			}
+			for _, merge := range node.FindAll("jointmerge") {
+				if a, b, ok := splitPair(merge.Value); ok {
+					params.JointMerge = append(params.JointMerge, [2]string{a, b})
+				}
+			}
+			phy.EditParams = params
+		case "break":
+			phy.Breaks = append(phy.Breaks, Break{
+				Model:       stringValue(node, "model"),
+				Ragdoll:     stringValue(node, "ragdoll"),
+				Health:      node.Float("health", 0),
+				FadeTime:    node.Float("fadetime", 0),
+				FadeMinDist: node.Float("fademindist", 0),
+				FadeMaxDist: node.Float("fademaxdist", 0),
+				Burst:       node.Float("burst", 0),
+				Debris:      node.Bool("debris", false),
+				Values:      node.Values(),
+			})
+		}
+	}
+
+	return nil
+}
+
+// stringValue returns a key's value, or an empty string
+func stringValue(node *internal.KeyValue, key string) string {
+	value, _ := node.String(key)
+	return value
+}
+
+// splitPair splits a "a,b" value
+func splitPair(value string) (string, string, bool) {
+	parts := strings.SplitN(value, ",", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// SolidByIndex returns the text properties of a solid, if present
+func (phy *Phy) SolidByIndex(index int) (*Solid, bool) {
+	for i := range phy.Solids {
+		if phy.Solids[i].Index == index {
+			return &phy.Solids[i], true
+		}
+	}
+	return nil, false
+}
+
+// SolidByName returns the text properties of the solid attached to a bone, if present
+func (phy *Phy) SolidByName(name string) (*Solid, bool) {
+	for i := range phy.Solids {
+		if strings.EqualFold(phy.Solids[i].Name, name) {
+			return &phy.Solids[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,556 @@
+package phy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/galaco/studiomodel/internal"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// compactSurfaceVersion is the VPHY version written by studiomdl
+	compactSurfaceVersion = 0x100
+	// ivpCompactSurfaceID is stored in the last word of IVP_Compact_Surface
+	ivpCompactSurfaceID = "IVPS"
+	// boxSizeScale is the fixed point scale of ledge tree box sizes, relative to the node radius
+	boxSizeScale = 250
+)
+
+// SolidDefinition is a solid to write
+type SolidDefinition struct {
+	// Hulls
+	// Convex pieces of the solid in IVP space, see FromSourceSpace.
+	// Each hull must be a closed triangle mesh.
+	Hulls []Hull
+	// Properties
+	// Written as the solid's "solid" text block. Index should match the solid's position.
+	Properties Solid
+}
+
+// Definition is everything needed to write a phy
+type Definition struct {
+	// Solids
+	Solids []SolidDefinition
+	// RagdollConstraints
+	RagdollConstraints []RagdollConstraint
+	// CollisionRules
+	// Omitted if nil
+	CollisionRules *CollisionRules
+	// EditParams
+	// Omitted if nil
+	EditParams *EditParams
+	// Breaks
+	Breaks []Break
+	// CheckSum
+	// Checksum of the mdl this phy belongs to
+	CheckSum int32
+}
+
+// NewDefinition returns a definition that reproduces an existing phy.
+// The geometry is preserved but Hulls renumbers vertices and the writer lays out its own
+// ledge tree, so writing the result is not byte identical to the original file. Files
+// produced by Writer do re-write identically.
+func NewDefinition(phy *Phy) *Definition {
+	definition := &Definition{
+		Solids:             make([]SolidDefinition, len(phy.Surfaces)),
+		RagdollConstraints: phy.RagdollConstraints,
+		CollisionRules:     phy.CollisionRules,
+		EditParams:         phy.EditParams,
+		Breaks:             phy.Breaks,
+		CheckSum:           phy.Header.CheckSum,
+	}
+	for i := range phy.Surfaces {
+		definition.Solids[i].Hulls = phy.Surfaces[i].Hulls()
+		if solid, ok := phy.SolidByIndex(i); ok {
+			definition.Solids[i].Properties = *solid
+		} else {
+			definition.Solids[i].Properties = Solid{Index: i}
+		}
+	}
+	return definition
+}
+
+// Writer
+type Writer struct {
+	// ByteOrder
+	// binary.LittleEndian for PC files, binary.BigEndian for console files
+	ByteOrder binary.ByteOrder
+}
+
+// NewWriter returns a little endian phy writer
+func NewWriter() *Writer {
+	return &Writer{
+		ByteOrder: binary.LittleEndian,
+	}
+}
+
+// Write writes a definition as a phy.
+// Text values are written without escape sequences, as the engine reads them back verbatim,
+// so a name or value containing a quote returns an error.
+func (writer *Writer) Write(stream io.Writer, definition *Definition) error {
+	if len(definition.Solids) == 0 {
+		return errors.New("phy requires at least one solid")
+	}
+
+	out := bytes.Buffer{}
+	if err := binary.Write(&out, writer.ByteOrder, header{
+		Size:       int32(unsafe.Sizeof(header{})),
+		SolidCount: int32(len(definition.Solids)),
+		CheckSum:   definition.CheckSum,
+	}); err != nil {
+		return err
+	}
+
+	for i := range definition.Solids {
+		surface, dragAxisAreas, err := writer.buildSurface(definition.Solids[i].Hulls)
+		if err != nil {
+			return fmt.Errorf("failed to build compact surface for solid %d: %w", i, err)
+		}
+
+		compactSize := int32(unsafe.Sizeof(compactSurfaceHeader{}))
+		if err := binary.Write(&out, writer.ByteOrder, compactSurfaceHeader{
+			// Size does not include the size field itself
+			Size:          compactSize - 4 + int32(len(surface)),
+			VPhysicsID:    int32(binary.LittleEndian.Uint32([]byte(VPhysicsID))),
+			Version:       compactSurfaceVersion,
+			SurfaceSize:   int32(len(surface)),
+			DragAxisAreas: dragAxisAreas,
+		}); err != nil {
+			return err
+		}
+		out.Write(surface)
+	}
+
+	if err := internal.WriteKeyValuesRaw(&out, textNodes(definition)); err != nil {
+		return fmt.Errorf("failed to write PHY text section: %w", err)
+	}
+	out.WriteByte(0)
+
+	_, err := stream.Write(out.Bytes())
+	return err
+}
+
+// surfaceBuilder lays out a single IVP_Compact_Surface
+type surfaceBuilder struct {
+	writer *Writer
+	buf    []byte
+	hulls  []Hull
+	// ledgeOffsets is the offset of each hull's ledge
+	ledgeOffsets []int
+}
+
+// buildSurface builds the IVP_Compact_Surface of a solid. Also returns the drag axis areas.
+func (writer *Writer) buildSurface(hulls []Hull) ([]byte, mgl32.Vec3, error) {
+	if len(hulls) == 0 {
+		return nil, mgl32.Vec3{}, errors.New("solid has no hulls")
+	}
+
+	builder := &surfaceBuilder{
+		writer: writer,
+		hulls:  hulls,
+	}
+	legacySize := int(unsafe.Sizeof(legacySurfaceHeader{}))
+	builder.buf = make([]byte, legacySize)
+
+	// Ledges and their triangles
+	builder.ledgeOffsets = make([]int, len(hulls))
+	for i := range hulls {
+		builder.ledgeOffsets[i] = len(builder.buf)
+		if err := builder.writeLedge(i); err != nil {
+			return nil, mgl32.Vec3{}, fmt.Errorf("hull %d: %w", i, err)
+		}
+	}
+
+	// Vertex pool, one run per ledge
+	for i := range hulls {
+		pool := len(builder.buf)
+		builder.putUint32(builder.ledgeOffsets[i], uint32(int32(pool-builder.ledgeOffsets[i])))
+		for _, v := range hulls[i].Vertices {
+			builder.buf = append(builder.buf, make([]byte, ivpPointSize)...)
+			builder.putVec3(len(builder.buf)-ivpPointSize, v)
+		}
+	}
+
+	// Ledge tree
+	ledges := make([]int, len(hulls))
+	for i := range ledges {
+		ledges[i] = i
+	}
+	treeRoot := len(builder.buf)
+	builder.writeNode(ledges)
+
+	// Surface header
+	props := ComputeMassProperties(hulls)
+	rotationInertia := props.PrincipalMoments()
+	radius := float32(0)
+	for i := range hulls {
+		for _, v := range hulls[i].Vertices {
+			radius = float32(math.Max(float64(radius), float64(v.Sub(props.CenterOfMass).Len())))
+		}
+	}
+	if len(builder.buf) >= 1<<24 {
+		return nil, mgl32.Vec3{}, fmt.Errorf("compact surface of %d bytes exceeds the format limit", len(builder.buf))
+	}
+
+	builder.putVec3(0, props.CenterOfMass)
+	builder.putVec3(12, rotationInertia)
+	builder.putFloat(24, radius)
+	builder.putUint32(28, writer.bitfield(0, 0, 8)|writer.bitfield(uint32(len(builder.buf)), 8, 24))
+	builder.putUint32(32, uint32(treeRoot))
+	copy(builder.buf[44:48], ivpCompactSurfaceID)
+
+	return builder.buf, dragAxisAreas(hulls), nil
+}
+
+// writeLedge appends an IVP_Compact_Ledge and its triangles for a hull.
+// The point offset is filled in once the vertex pool is placed.
+func (builder *surfaceBuilder) writeLedge(index int) error {
+	hull := &builder.hulls[index]
+	if len(hull.Triangles) == 0 {
+		return errors.New("hull has no triangles")
+	}
+	if len(hull.Vertices) > math.MaxUint16 {
+		return fmt.Errorf("hull has %d vertices, maximum is %d", len(hull.Vertices), math.MaxUint16)
+	}
+	if len(hull.Triangles) > 1<<12 {
+		return fmt.Errorf("hull has %d triangles, maximum is %d", len(hull.Triangles), 1<<12)
+	}
+
+	// Edges are addressed in 4 byte slots from the start of the ledge; each triangle is
+	// a header slot followed by its 3 edges
+	edgeSlot := func(triangle, edge int) int {
+		return (ivpLedgeSize+triangle*ivpTriangleSize)/4 + 1 + edge
+	}
+	edges := make(map[[2]uint16]int)
+	for i, triangle := range hull.Triangles {
+		for j := 0; j < 3; j++ {
+			if int(triangle[j]) >= len(hull.Vertices) {
+				return fmt.Errorf("triangle %d references vertex %d (hull has %d vertices)", i, triangle[j], len(hull.Vertices))
+			}
+			edge := [2]uint16{triangle[j], triangle[(j+1)%3]}
+			if _, ok := edges[edge]; ok {
+				return fmt.Errorf("edge %d-%d is used by more than one triangle", edge[0], edge[1])
+			}
+			edges[edge] = edgeSlot(i, j)
+		}
+	}
+
+	offset := len(builder.buf)
+	builder.buf = append(builder.buf, make([]byte, ivpLedgeSize+len(hull.Triangles)*ivpTriangleSize)...)
+
+	writer := builder.writer
+	builder.putUint32(offset+4, uint32(hull.ClientData))
+	builder.putUint32(offset+8, writer.bitfield(0, 0, 2)|
+		writer.bitfield(1, 2, 2)|
+		writer.bitfield(uint32((ivpLedgeSize+len(hull.Triangles)*ivpTriangleSize)/16), 8, 24))
+	builder.putUint16(offset+12, uint16(len(hull.Triangles)))
+
+	pierce := pierceTriangles(hull)
+	for i, triangle := range hull.Triangles {
+		triangleOffset := offset + ivpLedgeSize + i*ivpTriangleSize
+		builder.putUint32(triangleOffset, writer.bitfield(uint32(i), 0, 12)|writer.bitfield(uint32(pierce[i]), 12, 12))
+
+		for j := 0; j < 3; j++ {
+			opposite, ok := edges[[2]uint16{triangle[(j+1)%3], triangle[j]}]
+			if !ok {
+				return fmt.Errorf("edge %d-%d of triangle %d has no opposite edge, hull is not closed", triangle[j], triangle[(j+1)%3], i)
+			}
+			relative := opposite - edgeSlot(i, j)
+			builder.putUint32(triangleOffset+4+j*4, writer.bitfield(uint32(triangle[j]), 0, 16)|
+				writer.bitfield(uint32(relative), 16, 15))
+		}
+	}
+
+	return nil
+}
+
+// pierceTriangles returns the pierce index of each triangle of a convex hull: the triangle
+// a ray from the triangle's centre along its inverted normal leaves the hull through.
+// This follows how IVP's ledge generator fills the field, as a starting point for searches
+// on the far side of the hull. Degenerate triangles point at themselves.
+func pierceTriangles(hull *Hull) []int {
+	normals := make([]mgl32.Vec3, len(hull.Triangles))
+	for i, triangle := range hull.Triangles {
+		a, b, c := hull.Vertices[triangle[0]], hull.Vertices[triangle[1]], hull.Vertices[triangle[2]]
+		normals[i] = b.Sub(a).Cross(c.Sub(a))
+		if length := normals[i].Len(); length > 0 {
+			normals[i] = normals[i].Mul(1 / length)
+		}
+	}
+
+	pierce := make([]int, len(hull.Triangles))
+	for i, triangle := range hull.Triangles {
+		origin := hull.Vertices[triangle[0]].Add(hull.Vertices[triangle[1]]).Add(hull.Vertices[triangle[2]]).Mul(1.0 / 3)
+		direction := normals[i].Mul(-1)
+
+		// A ray inside a convex hull leaves through the nearest plane it is heading out of
+		pierce[i] = i
+		nearest := math.Inf(1)
+		for j, other := range hull.Triangles {
+			facing := float64(normals[j].Dot(direction))
+			if j == i || facing <= 0 {
+				continue
+			}
+			distance := float64(normals[j].Dot(hull.Vertices[other[0]].Sub(origin))) / facing
+			if distance < nearest {
+				nearest = distance
+				pierce[i] = j
+			}
+		}
+	}
+
+	return pierce
+}
+
+// writeNode appends a ledge tree node over a set of ledges, followed by its children.
+// The left child directly follows its parent.
+func (builder *surfaceBuilder) writeNode(ledges []int) {
+	offset := len(builder.buf)
+	builder.buf = append(builder.buf, make([]byte, ivpLedgeTreeNodeSize)...)
+
+	// Bounds of every vertex under this node
+	minimum := mgl32.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	maximum := minimum.Mul(-1)
+	for _, ledge := range ledges {
+		for _, v := range builder.hulls[ledge].Vertices {
+			for k := 0; k < 3; k++ {
+				minimum[k] = float32(math.Min(float64(minimum[k]), float64(v[k])))
+				maximum[k] = float32(math.Max(float64(maximum[k]), float64(v[k])))
+			}
+		}
+	}
+	center := minimum.Add(maximum).Mul(0.5)
+	radius := float32(0)
+	for _, ledge := range ledges {
+		for _, v := range builder.hulls[ledge].Vertices {
+			radius = float32(math.Max(float64(radius), float64(v.Sub(center).Len())))
+		}
+	}
+
+	builder.putVec3(offset+8, center)
+	builder.putFloat(offset+20, radius)
+	for k := 0; k < 3; k++ {
+		size := boxSizeScale
+		if radius > 0 {
+			size = int(math.Ceil(float64((maximum[k] - minimum[k]) / 2 / radius * boxSizeScale)))
+		}
+		builder.buf[offset+24+k] = uint8(min(max(size, 0), math.MaxUint8))
+	}
+
+	if len(ledges) == 1 {
+		builder.putUint32(offset+4, uint32(int32(builder.ledgeOffsets[ledges[0]]-offset)))
+		return
+	}
+
+	// Split along the longest axis of the ledge centres
+	axis := 0
+	extent := maximum.Sub(minimum)
+	for k := 1; k < 3; k++ {
+		if extent[k] > extent[axis] {
+			axis = k
+		}
+	}
+	sorted := append([]int(nil), ledges...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return builder.hullCenter(sorted[a])[axis] < builder.hullCenter(sorted[b])[axis]
+	})
+	half := len(sorted) / 2
+
+	builder.writeNode(sorted[:half])
+	right := len(builder.buf)
+	builder.writeNode(sorted[half:])
+	builder.putUint32(offset, uint32(int32(right-offset)))
+}
+
+// hullCenter returns the average vertex of a hull
+func (builder *surfaceBuilder) hullCenter(index int) mgl32.Vec3 {
+	var center mgl32.Vec3
+	vertices := builder.hulls[index].Vertices
+	for _, v := range vertices {
+		center = center.Add(v)
+	}
+	if len(vertices) > 0 {
+		center = center.Mul(1 / float32(len(vertices)))
+	}
+	return center
+}
+
+// putUint32 writes a 32 bit word
+func (builder *surfaceBuilder) putUint32(offset int, value uint32) {
+	builder.writer.ByteOrder.PutUint32(builder.buf[offset:offset+4], value)
+}
+
+// putUint16 writes a 16 bit word
+func (builder *surfaceBuilder) putUint16(offset int, value uint16) {
+	builder.writer.ByteOrder.PutUint16(builder.buf[offset:offset+2], value)
+}
+
+// putFloat writes a float
+func (builder *surfaceBuilder) putFloat(offset int, value float32) {
+	builder.putUint32(offset, math.Float32bits(value))
+}
+
+// putVec3 writes 3 consecutive floats
+func (builder *surfaceBuilder) putVec3(offset int, v mgl32.Vec3) {
+	for k := 0; k < 3; k++ {
+		builder.putFloat(offset+k*4, v[k])
+	}
+}
+
+// bitfield places a value into a C bitfield. See Reader.bitfield
+func (writer *Writer) bitfield(value uint32, shift uint, width uint) uint32 {
+	if writer.ByteOrder == binary.BigEndian {
+		shift = 32 - shift - width
+	}
+	return (value & (1<<width - 1)) << shift
+}
+
+// dragAxisAreas returns the area of the solid projected onto each axis plane
+func dragAxisAreas(hulls []Hull) mgl32.Vec3 {
+	var areas mgl32.Vec3
+	for i := range hulls {
+		for _, triangle := range hulls[i].Triangles {
+			a := hulls[i].Vertices[triangle[0]]
+			normal := hulls[i].Vertices[triangle[1]].Sub(a).Cross(hulls[i].Vertices[triangle[2]].Sub(a))
+			for k := 0; k < 3; k++ {
+				areas[k] += float32(math.Abs(float64(normal[k])))
+			}
+		}
+	}
+	// Every direction is covered twice by a closed hull, and the cross product is twice the area
+	return areas.Mul(0.25)
+}
+
+// textNodes builds the key values text section of a definition
+func textNodes(definition *Definition) []*internal.KeyValue {
+	nodes := make([]*internal.KeyValue, 0)
+
+	for i := range definition.Solids {
+		solid := &definition.Solids[i].Properties
+		node := &internal.KeyValue{Key: "solid", IsBlock: true}
+		addInt(node, "index", solid.Index)
+		addString(node, "name", solid.Name)
+		if solid.Parent != "" {
+			addString(node, "parent", solid.Parent)
+		}
+		addFloat(node, "mass", solid.Mass)
+		addString(node, "surfaceprop", solid.SurfaceProp)
+		addOptionalFloat(node, solid.Values, "damping", solid.Damping)
+		addOptionalFloat(node, solid.Values, "rotdamping", solid.RotDamping)
+		addOptionalFloat(node, solid.Values, "drag", solid.Drag)
+		addOptionalFloat(node, solid.Values, "inertia", solid.Inertia)
+		addOptionalFloat(node, solid.Values, "volume", solid.Volume)
+		addExtraValues(node, solid.Values)
+		nodes = append(nodes, node)
+	}
+
+	for i := range definition.RagdollConstraints {
+		constraint := &definition.RagdollConstraints[i]
+		node := &internal.KeyValue{Key: "ragdollconstraint", IsBlock: true}
+		addInt(node, "parent", constraint.Parent)
+		addInt(node, "child", constraint.Child)
+		addFloat(node, "xmin", constraint.XMin)
+		addFloat(node, "xmax", constraint.XMax)
+		addFloat(node, "xfriction", constraint.XFriction)
+		addFloat(node, "ymin", constraint.YMin)
+		addFloat(node, "ymax", constraint.YMax)
+		addFloat(node, "yfriction", constraint.YFriction)
+		addFloat(node, "zmin", constraint.ZMin)
+		addFloat(node, "zmax", constraint.ZMax)
+		addFloat(node, "zfriction", constraint.ZFriction)
+		addExtraValues(node, constraint.Values)
+		nodes = append(nodes, node)
+	}
+
+	if rules := definition.CollisionRules; rules != nil {
+		node := &internal.KeyValue{Key: "collisionrules", IsBlock: true}
+		if !rules.SelfCollisions {
+			addInt(node, "selfcollisions", 0)
+		}
+		for _, pair := range rules.CollisionPairs {
+			addString(node, "collisionpair", fmt.Sprintf("%d,%d", pair[0], pair[1]))
+		}
+		nodes = append(nodes, node)
+	}
+
+	if params := definition.EditParams; params != nil {
+		node := &internal.KeyValue{Key: "editparams", IsBlock: true}
+		addString(node, "rootname", params.RootName)
+		addFloat(node, "totalmass", params.TotalMass)
+		if params.Concave {
+			addInt(node, "concave", 1)
+		}
+		for _, merge := range params.JointMerge {
+			addString(node, "jointmerge", merge[0]+","+merge[1])
+		}
+		addExtraValues(node, params.Values)
+		nodes = append(nodes, node)
+	}
+
+	for i := range definition.Breaks {
+		piece := &definition.Breaks[i]
+		node := &internal.KeyValue{Key: "break", IsBlock: true}
+		addString(node, "model", piece.Model)
+		if piece.Ragdoll != "" {
+			addString(node, "ragdoll", piece.Ragdoll)
+		}
+		addOptionalFloat(node, piece.Values, "health", piece.Health)
+		addOptionalFloat(node, piece.Values, "fadetime", piece.FadeTime)
+		addOptionalFloat(node, piece.Values, "fademindist", piece.FadeMinDist)
+		addOptionalFloat(node, piece.Values, "fademaxdist", piece.FadeMaxDist)
+		addOptionalFloat(node, piece.Values, "burst", piece.Burst)
+		if piece.Debris {
+			addInt(node, "debris", 1)
+		}
+		addExtraValues(node, piece.Values)
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// addString adds a key to a block
+func addString(node *internal.KeyValue, key string, value string) {
+	node.Children = append(node.Children, &internal.KeyValue{Key: key, Value: value})
+}
+
+// addInt adds an integer key to a block
+func addInt(node *internal.KeyValue, key string, value int) {
+	addString(node, key, strconv.Itoa(value))
+}
+
+// addFloat adds a float key to a block
+func addFloat(node *internal.KeyValue, key string, value float32) {
+	addString(node, key, strconv.FormatFloat(float64(value), 'f', -1, 32))
+}
+
+// addOptionalFloat adds a float key if it is non-zero or was present when read.
+// The engine treats missing keys as non-zero defaults, so zero is not always written.
+func addOptionalFloat(node *internal.KeyValue, values map[string]string, key string, value float32) {
+	if _, ok := values[key]; ok || value != 0 {
+		addFloat(node, key, value)
+	}
+}
+
+// addExtraValues adds any keys not already written to a block, in sorted order
+func addExtraValues(node *internal.KeyValue, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if node.Find(key) == nil && !strings.EqualFold(key, "collisionpair") && !strings.EqualFold(key, "jointmerge") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addString(node, key, values[key])
+	}
+}
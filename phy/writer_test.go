@@ -0,0 +1,197 @@
+package phy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testDefinition is a two solid ragdoll: a single box and a solid made of two boxes
+func testDefinition() *Definition {
+	upper := boxHull(mgl32.Vec3{-0.5, 0.5, -0.5}, mgl32.Vec3{0.5, 1.5, 0.5})
+	upper.ClientData = 1
+	lower := boxHull(mgl32.Vec3{-0.5, -1.5, -0.5}, mgl32.Vec3{0.5, -0.5, 0.5})
+	lower.ClientData = 1
+
+	return &Definition{
+		Solids: []SolidDefinition{
+			{
+				Hulls:      []Hull{boxHull(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1})},
+				Properties: Solid{Index: 0, Name: "pelvis", Parent: "", Mass: 10, SurfaceProp: "flesh", Damping: 0.1},
+			},
+			{
+				Hulls:      []Hull{upper, lower},
+				Properties: Solid{Index: 1, Name: "spine", Parent: "pelvis", Mass: 5, SurfaceProp: "metal"},
+			},
+		},
+		RagdollConstraints: []RagdollConstraint{
+			{Parent: 0, Child: 1, XMin: -30, XMax: 30, YMin: -10, YMax: 10, ZMin: -45, ZMax: 45, XFriction: 1},
+		},
+		CollisionRules: &CollisionRules{SelfCollisions: false, CollisionPairs: [][2]int{{0, 1}}},
+		EditParams:     &EditParams{RootName: "pelvis", TotalMass: 15},
+		CheckSum:       0x1234,
+	}
+}
+
+func writeDefinition(t *testing.T, order binary.ByteOrder, definition *Definition) []byte {
+	t.Helper()
+	writer := NewWriter()
+	writer.ByteOrder = order
+	out := bytes.Buffer{}
+	if err := writer.Write(&out, definition); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return out.Bytes()
+}
+
+// sameSurface checks two hulls describe the same geometry, ignoring vertex numbering
+func sameSurface(a, b Hull) bool {
+	if len(a.Triangles) != len(b.Triangles) || len(a.Vertices) != len(b.Vertices) {
+		return false
+	}
+	for i := range a.Triangles {
+		for j := 0; j < 3; j++ {
+			if !a.Vertices[a.Triangles[i][j]].ApproxEqual(b.Vertices[b.Triangles[i][j]]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		order binary.ByteOrder
+	}{
+		{"little endian", binary.LittleEndian},
+		{"big endian", binary.BigEndian},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			definition := testDefinition()
+			first := writeDefinition(t, tc.order, definition)
+
+			phy, err := ReadFromStream(bytes.NewReader(first))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if phy.ByteOrder != tc.order {
+				t.Errorf("byte order = %v, want %v", phy.ByteOrder, tc.order)
+			}
+			if phy.TextErr != nil {
+				t.Fatalf("text error: %v", phy.TextErr)
+			}
+			if phy.Header.CheckSum != 0x1234 || len(phy.Surfaces) != 2 {
+				t.Fatalf("checksum %x with %d surfaces", phy.Header.CheckSum, len(phy.Surfaces))
+			}
+
+			for i, solid := range definition.Solids {
+				hulls := phy.Surfaces[i].Hulls()
+				if len(hulls) != len(solid.Hulls) {
+					t.Fatalf("solid %d has %d hulls, want %d", i, len(hulls), len(solid.Hulls))
+				}
+				// Ledges are read back in ledge tree order, which need not match the definition
+				for j := range hulls {
+					found := false
+					for k := range solid.Hulls {
+						found = found || sameSurface(hulls[j], solid.Hulls[k])
+					}
+					if !found {
+						t.Errorf("solid %d hull %d geometry matches no written hull", i, j)
+					}
+				}
+
+				props := ComputeMassProperties(solid.Hulls)
+				if !phy.Surfaces[i].MassCenter.ApproxEqualThreshold(props.CenterOfMass, 1e-4) {
+					t.Errorf("solid %d mass center = %v, want %v", i, phy.Surfaces[i].MassCenter, props.CenterOfMass)
+				}
+				if discrepancy := phy.Surfaces[i].CompareStoredMass(); discrepancy.InertiaError > 1e-4 {
+					t.Errorf("solid %d stored inertia %v differs from computed %v", i, discrepancy.Stored, discrepancy.Computed)
+				}
+
+				stored, ok := phy.SolidByIndex(i)
+				if !ok || stored.Name != solid.Properties.Name || stored.Mass != solid.Properties.Mass ||
+					stored.SurfaceProp != solid.Properties.SurfaceProp {
+					t.Errorf("solid %d text = %+v, want %+v", i, stored, solid.Properties)
+				}
+			}
+
+			if len(phy.RagdollConstraints) != 1 || phy.RagdollConstraints[0].ZMax != 45 || phy.RagdollConstraints[0].Child != 1 {
+				t.Errorf("ragdoll constraints = %+v", phy.RagdollConstraints)
+			}
+			if phy.CollisionRules == nil || phy.CollisionRules.SelfCollisions || len(phy.CollisionRules.CollisionPairs) != 1 {
+				t.Errorf("collision rules = %+v", phy.CollisionRules)
+			}
+			if phy.EditParams == nil || phy.EditParams.RootName != "pelvis" || phy.EditParams.TotalMass != 15 {
+				t.Errorf("edit params = %+v", phy.EditParams)
+			}
+
+			// Hulls renumbers vertices, so only files this writer produced re-write identically
+			second := writeDefinition(t, tc.order, NewDefinition(phy))
+			reread, err := ReadFromStream(bytes.NewReader(second))
+			if err != nil {
+				t.Fatalf("second read failed: %v", err)
+			}
+			third := writeDefinition(t, tc.order, NewDefinition(reread))
+			if !bytes.Equal(second, third) {
+				t.Error("re-writing a written phy is not byte stable")
+			}
+		})
+	}
+}
+
+func TestWriteRejectsOpenHull(t *testing.T) {
+	hull := boxHull(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1})
+	hull.Triangles = hull.Triangles[:len(hull.Triangles)-1]
+	definition := &Definition{Solids: []SolidDefinition{{Hulls: []Hull{hull}}}}
+
+	if err := NewWriter().Write(&bytes.Buffer{}, definition); err == nil {
+		t.Fatal("expected an error for a hull that is not closed")
+	}
+}
+
+func TestPierceTriangles(t *testing.T) {
+	hull := boxHull(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1})
+	pierce := pierceTriangles(&hull)
+
+	// Faces come in pairs, each opposite face pair is 2 apart
+	opposite := map[int]int{0: 2, 2: 0, 4: 6, 6: 4, 8: 10, 10: 8}
+	for i, j := range pierce {
+		face := i / 2 * 2
+		if j/2*2 != opposite[face] {
+			t.Errorf("triangle %d pierces triangle %d, want a triangle of face %d", i, j, opposite[face])
+		}
+	}
+}
+
+func TestWriteTextIsUnescaped(t *testing.T) {
+	definition := testDefinition()
+	definition.Solids[0].Properties.Name = `models\props\new_crate`
+	definition.Solids[0].Properties.Values = map[string]string{"note": `C:\temp\`}
+	data := writeDefinition(t, binary.LittleEndian, definition)
+
+	phy, err := ReadFromStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if phy.TextErr != nil {
+		t.Fatalf("text error: %v", phy.TextErr)
+	}
+	// The engine does not resolve escapes, so backslashes must be written once
+	if !bytes.Contains(data, []byte(`"name" "models\props\new_crate"`)) {
+		t.Errorf("text section = %q", phy.Text)
+	}
+	solid, ok := phy.SolidByIndex(0)
+	if !ok || solid.Name != `models\props\new_crate` || solid.Values["note"] != `C:\temp\` {
+		t.Errorf("solid = %+v", solid)
+	}
+
+	definition.Solids[0].Properties.Name = `say "hi"`
+	if err := NewWriter().Write(&bytes.Buffer{}, definition); err == nil {
+		t.Error("expected an error for a name containing a quote")
+	}
+}
@@ -0,0 +1,93 @@
+package studiomodel
+
+import (
+	"errors"
+
+	"github.com/galaco/studiomodel/phy"
+	"github.com/galaco/studiomodel/propdata"
+)
+
+// Gib is a piece spawned when a model breaks
+type Gib struct {
+	// Model
+	Model string
+	// Ragdoll
+	// Ragdoll model spawned instead of Model, if set
+	Ragdoll string
+	// Health
+	Health float32
+	// FadeTime
+	FadeTime float32
+	// FadeMinDist
+	FadeMinDist float32
+	// FadeMaxDist
+	FadeMaxDist float32
+	// Burst
+	Burst float32
+	// Debris
+	// Debris gibs do not collide with players or NPCs
+	Debris bool
+	// Generic
+	// Set for gibs from a propdata.txt BreakableModels set rather than the model's phy.
+	// The engine spawns PropData.BreakableCount of these, picked at random
+	Generic bool
+	// Break
+	// The phy break block this gib was built from, nil for generic gibs
+	Break *phy.Break
+}
+
+// PropData returns the model's prop_data with its base class from db applied.
+// db may be nil. Returns nil if the model has no prop_data.
+func (model *StudioModel) PropData(db *propdata.Database) (*propdata.PropData, error) {
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to read prop data")
+	}
+	if model.Mdl.KeyValues == "" {
+		return nil, nil
+	}
+	return propdata.Parse(model.Mdl.KeyValues, db)
+}
+
+// Gibs returns what the model breaks into. Model specific gibs from the phy break blocks
+// take precedence; otherwise the generic gib set named by prop_data is used, if db is set.
+// Returns nothing for models that do not break.
+func (model *StudioModel) Gibs(db *propdata.Database) ([]Gib, error) {
+	data, err := model.PropData(db)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil || !data.IsBreakable() {
+		return nil, nil
+	}
+
+	gibs := make([]Gib, 0)
+	if model.Phy != nil && len(model.Phy.Breaks) > 0 {
+		for i := range model.Phy.Breaks {
+			piece := &model.Phy.Breaks[i]
+			gibs = append(gibs, Gib{
+				Model:       piece.Model,
+				Ragdoll:     piece.Ragdoll,
+				Health:      piece.Health,
+				FadeTime:    piece.FadeTime,
+				FadeMinDist: piece.FadeMinDist,
+				FadeMaxDist: piece.FadeMaxDist,
+				Burst:       piece.Burst,
+				Debris:      piece.Debris,
+				Break:       piece,
+			})
+		}
+		return gibs, nil
+	}
+
+	if db != nil && data.BreakableCount > 0 {
+		for _, gib := range db.GenericGibs(data) {
+			gibs = append(gibs, Gib{
+				Model:   gib,
+				Debris:  true,
+				Generic: true,
+			})
+		}
+	}
+
+	return gibs, nil
+}
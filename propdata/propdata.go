@@ -0,0 +1,265 @@
+package propdata
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/galaco/studiomodel/internal"
+)
+
+// DefaultPath is where the engine reads prop data base classes from
+const DefaultPath = "scripts/propdata.txt"
+
+// MultiplayerBreak is where gibs are simulated in multiplayer games
+type MultiplayerBreak string
+
+const (
+	// MultiplayerBreakDefault leaves the decision to the game
+	MultiplayerBreakDefault MultiplayerBreak = ""
+	// MultiplayerBreakServer spawns server side gibs
+	MultiplayerBreakServer MultiplayerBreak = "server"
+	// MultiplayerBreakClient spawns client side gibs
+	MultiplayerBreakClient MultiplayerBreak = "client"
+	// MultiplayerBreakBoth spawns gibs on both
+	MultiplayerBreakBoth MultiplayerBreak = "both"
+)
+
+// PropData is a model's prop_data block with its base class applied
+type PropData struct {
+	// Base
+	// Name of the propdata.txt base class, e.g. "Wooden.Medium"
+	Base string
+	// Health
+	// 0 means the prop does not break
+	Health int
+	// DamageTable
+	DamageTable string
+	// BulletDamageScale
+	// "dmg.bullets"
+	BulletDamageScale float32
+	// ClubDamageScale
+	// "dmg.club"
+	ClubDamageScale float32
+	// ExplosiveDamageScale
+	// "dmg.explosive"
+	ExplosiveDamageScale float32
+	// ExplosiveDamage
+	// Damage dealt when the prop breaks, 0 if it does not explode
+	ExplosiveDamage float32
+	// ExplosiveRadius
+	ExplosiveRadius float32
+	// BreakableModel
+	// Generic gib set from the BreakableModels section of propdata.txt
+	BreakableModel string
+	// BreakableCount
+	// Number of generic gibs to spawn
+	BreakableCount int
+	// BreakableSkin
+	BreakableSkin int
+	// MultiplayerBreak
+	MultiplayerBreak MultiplayerBreak
+	// AllowStatic
+	// Whether the prop may be placed as prop_static
+	AllowStatic bool
+	// Values holds every resolved key, lowercased
+	Values map[string]string
+}
+
+// IsBreakable returns whether the prop can be broken
+func (data *PropData) IsBreakable() bool {
+	return data.Health > 0
+}
+
+// IsExplosive returns whether the prop deals damage when it breaks
+func (data *PropData) IsExplosive() bool {
+	return data.ExplosiveDamage > 0 && data.ExplosiveRadius > 0
+}
+
+// Database holds the base classes and generic gib sets of a propdata.txt
+type Database struct {
+	// bases maps lowercased base class names to their values
+	bases map[string]map[string]string
+	// BreakableModels
+	// Gib model paths keyed by lowercased breakable_model name
+	BreakableModels map[string][]string
+}
+
+// NewDatabase returns an empty Database
+func NewDatabase() *Database {
+	return &Database{
+		bases:           make(map[string]map[string]string),
+		BreakableModels: make(map[string][]string),
+	}
+}
+
+// ReadFromStream parses a propdata.txt
+func ReadFromStream(stream io.Reader) (*Database, error) {
+	buf := bytes.Buffer{}
+	if _, err := buf.ReadFrom(stream); err != nil {
+		return nil, err
+	}
+	nodes, err := internal.ParseKeyValues(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prop data: %w", err)
+	}
+
+	db := NewDatabase()
+	for _, root := range nodes {
+		if !root.IsBlock || !strings.EqualFold(root.Key, "PropData") {
+			continue
+		}
+		for _, node := range root.Children {
+			if !node.IsBlock {
+				continue
+			}
+			if strings.EqualFold(node.Key, "BreakableModels") {
+				for _, set := range node.Children {
+					if !set.IsBlock {
+						continue
+					}
+					models := make([]string, 0, len(set.Children))
+					for _, model := range set.Children {
+						if !model.IsBlock {
+							models = append(models, model.Key)
+						}
+					}
+					db.BreakableModels[strings.ToLower(set.Key)] = models
+				}
+				continue
+			}
+			db.bases[strings.ToLower(node.Key)] = node.Values()
+		}
+	}
+
+	return db, nil
+}
+
+// LoadFromFS reads a propdata.txt from a filesystem
+func LoadFromFS(fsys fs.FS, path string) (*Database, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFromStream(file)
+}
+
+// Bases returns the name of every base class, sorted and lowercased
+func (db *Database) Bases() []string {
+	names := make([]string, 0, len(db.bases))
+	for name := range db.bases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse reads the prop_data block from mdl $keyvalues text and applies its base class.
+// db may be nil, in which case the base class is recorded but not applied.
+// Returns nil if the text has no prop_data block.
+func Parse(keyValues string, db *Database) (*PropData, error) {
+	nodes, err := internal.ParseKeyValues(keyValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model key values: %w", err)
+	}
+
+	block := findBlock(nodes, "prop_data")
+	if block == nil {
+		return nil, nil
+	}
+
+	values := make(map[string]string)
+	own := block.Values()
+	if base, ok := own["base"]; ok && db != nil {
+		inherited, err := db.resolve(strings.ToLower(base), make(map[string]bool))
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range inherited {
+			values[key] = value
+		}
+	}
+	for key, value := range own {
+		values[key] = value
+	}
+
+	resolved := &internal.KeyValue{IsBlock: true}
+	for key, value := range values {
+		resolved.Children = append(resolved.Children, &internal.KeyValue{Key: key, Value: value})
+	}
+
+	return &PropData{
+		Base:                 own["base"],
+		Health:               resolved.Int("health", 0),
+		DamageTable:          values["damage_table"],
+		BulletDamageScale:    resolved.Float("dmg.bullets", 1),
+		ClubDamageScale:      resolved.Float("dmg.club", 1),
+		ExplosiveDamageScale: resolved.Float("dmg.explosive", 1),
+		ExplosiveDamage:      resolved.Float("explosive_damage", 0),
+		ExplosiveRadius:      resolved.Float("explosive_radius", 0),
+		BreakableModel:       values["breakable_model"],
+		BreakableCount:       resolved.Int("breakable_count", 0),
+		BreakableSkin:        resolved.Int("breakable_skin", 0),
+		MultiplayerBreak:     MultiplayerBreak(strings.ToLower(values["multiplayer_break"])),
+		AllowStatic:          resolved.Bool("allowstatic", false),
+		Values:               values,
+	}, nil
+}
+
+// resolve merges a base class over its own bases
+func (db *Database) resolve(name string, visited map[string]bool) (map[string]string, error) {
+	base, ok := db.bases[name]
+	if !ok {
+		return nil, fmt.Errorf("prop data base %q is not defined", name)
+	}
+	if visited[name] {
+		return nil, fmt.Errorf("prop data base %q inherits from itself", name)
+	}
+	visited[name] = true
+
+	values := make(map[string]string)
+	if parent, ok := base["base"]; ok {
+		inherited, err := db.resolve(strings.ToLower(parent), visited)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range inherited {
+			values[key] = value
+		}
+	}
+	for key, value := range base {
+		values[key] = value
+	}
+	delete(values, "base")
+
+	return values, nil
+}
+
+// GenericGibs returns the gib models for a prop's breakable_model, without count limits
+func (db *Database) GenericGibs(data *PropData) []string {
+	if data.BreakableModel == "" {
+		return nil
+	}
+	return db.BreakableModels[strings.ToLower(data.BreakableModel)]
+}
+
+// findBlock returns the first block with a key, searching nested blocks depth first.
+// Compiled models wrap their key values in an "mdlkeyvalue" block.
+func findBlock(nodes []*internal.KeyValue, key string) *internal.KeyValue {
+	for _, node := range nodes {
+		if !node.IsBlock {
+			continue
+		}
+		if strings.EqualFold(node.Key, key) {
+			return node
+		}
+		if found := findBlock(node.Children, key); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package propdata
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testPropData = `"PropData"
+{
+	// Base classes
+	"Wooden.Base"
+	{
+		"dmg.club"	"2.0"
+		"damage_table"	"wood"
+		"breakable_model"	"WoodChunks"
+	}
+	"Wooden.Small"
+	{
+		"base"	"Wooden.Base"
+		"health"	"20"
+		"dmg.bullets"	"0.5"
+		"breakable_count"	"2"
+	}
+	"Explosive.Barrel"
+	{
+		"health"	"10"
+		"explosive_damage"	"100"
+		"explosive_radius"	"256"
+		"multiplayer_break"	"Both"
+	}
+	"Loop.A"
+	{
+		"base"	"Loop.B"
+	}
+	"Loop.B"
+	{
+		"base"	"loop.a"
+	}
+
+	"BreakableModels"
+	{
+		"WoodChunks"
+		{
+			"models/gibs/wood_gib01a.mdl"	"1"
+			"models/gibs/wood_gib01b.mdl"	"1"
+		}
+	}
+}
+`
+
+// mdlKeyValues wraps a prop_data block the way studiomdl compiles $keyvalues
+func mdlKeyValues(propData string) string {
+	return "mdlkeyvalue\n{\n\tprop_data\n\t{\n" + propData + "\t}\n}\n"
+}
+
+func TestParse(t *testing.T) {
+	db, err := ReadFromStream(strings.NewReader(testPropData))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		keyValues string
+		// noDb parses without a database
+		noDb bool
+		want *PropData
+	}{
+		{
+			name:      "base applied",
+			keyValues: mdlKeyValues("\t\t\"base\"\t\"Wooden.Small\"\n"),
+			want: &PropData{Base: "Wooden.Small", Health: 20, DamageTable: "wood", BulletDamageScale: 0.5, ClubDamageScale: 2,
+				ExplosiveDamageScale: 1, BreakableModel: "WoodChunks", BreakableCount: 2},
+		},
+		{
+			name:      "own values override base",
+			keyValues: mdlKeyValues("\t\t\"base\"\t\"wooden.small\"\n\t\t\"health\"\t\"50\"\n\t\t\"dmg.club\"\t\"0\"\n\t\t\"allowstatic\"\t\"1\"\n"),
+			want: &PropData{Base: "wooden.small", Health: 50, DamageTable: "wood", BulletDamageScale: 0.5, ClubDamageScale: 0,
+				ExplosiveDamageScale: 1, BreakableModel: "WoodChunks", BreakableCount: 2, AllowStatic: true},
+		},
+		{
+			name:      "without the mdlkeyvalue wrapper",
+			keyValues: "prop_data { \"base\" \"Explosive.Barrel\" }",
+			want: &PropData{Base: "Explosive.Barrel", Health: 10, BulletDamageScale: 1, ClubDamageScale: 1, ExplosiveDamageScale: 1,
+				ExplosiveDamage: 100, ExplosiveRadius: 256, MultiplayerBreak: MultiplayerBreakBoth},
+		},
+		{
+			name:      "damage scales fall back",
+			keyValues: mdlKeyValues("\t\t\"health\"\t\"5\"\n\t\t\"dmg.bullets\"\t\"not a number\"\n"),
+			want:      &PropData{Health: 5, BulletDamageScale: 1, ClubDamageScale: 1, ExplosiveDamageScale: 1},
+		},
+		{
+			name:      "no database",
+			keyValues: mdlKeyValues("\t\t\"base\"\t\"Wooden.Small\"\n\t\t\"health\"\t\"3\"\n"),
+			noDb:      true,
+			want:      &PropData{Base: "Wooden.Small", Health: 3, BulletDamageScale: 1, ClubDamageScale: 1, ExplosiveDamageScale: 1},
+		},
+		{
+			name:      "other key values only",
+			keyValues: "mdlkeyvalue\n{\n\tphysgun_interactions\n\t{\n\t\t\"onfirstimpact\"\t\"break\"\n\t}\n}\n",
+			want:      nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			database := db
+			if tc.noDb {
+				database = nil
+			}
+			got, err := Parse(tc.keyValues, database)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if tc.want == nil || got == nil {
+				if got != tc.want {
+					t.Fatalf("got %+v, want %+v", got, tc.want)
+				}
+				return
+			}
+			// Values is checked separately
+			values := got.Values
+			got.Values = nil
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v\nwant %+v", got, tc.want)
+			}
+			if _, ok := values["base"]; !ok && tc.want.Base != "" {
+				t.Error("values should keep the model's own base key")
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	db, err := ReadFromStream(strings.NewReader(testPropData))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		keyValues string
+		want      string
+	}{
+		{"undefined base", mdlKeyValues("\t\t\"base\"\t\"Glass.Window\"\n"), `prop data base "glass.window" is not defined`},
+		{"base cycle", mdlKeyValues("\t\t\"base\"\t\"Loop.A\"\n"), "inherits from itself"},
+		{"malformed", "mdlkeyvalue { prop_data {", "failed to parse model key values"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.keyValues, db); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenericGibs(t *testing.T) {
+	db, err := ReadFromStream(strings.NewReader(testPropData))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	want := []string{"explosive.barrel", "loop.a", "loop.b", "wooden.base", "wooden.small"}
+	if bases := db.Bases(); !slices.Equal(bases, want) {
+		t.Errorf("bases = %v, want %v", bases, want)
+	}
+
+	gibs := db.GenericGibs(&PropData{BreakableModel: "woodchunks"})
+	if !slices.Equal(gibs, []string{"models/gibs/wood_gib01a.mdl", "models/gibs/wood_gib01b.mdl"}) {
+		t.Errorf("gibs = %v", gibs)
+	}
+	if gibs := db.GenericGibs(&PropData{}); gibs != nil {
+		t.Errorf("gibs without a breakable_model = %v", gibs)
+	}
+}
@@ -0,0 +1,101 @@
+package studiomodel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/galaco/studiomodel/mdl"
+	"github.com/galaco/studiomodel/phy"
+	"github.com/galaco/studiomodel/propdata"
+)
+
+func TestGibs(t *testing.T) {
+	db, err := propdata.ReadFromStream(strings.NewReader(`"PropData"
+{
+	"Wooden.Small"
+	{
+		"health"	"20"
+		"breakable_model"	"WoodChunks"
+		"breakable_count"	"2"
+	}
+	"BreakableModels"
+	{
+		"WoodChunks"
+		{
+			"models/gibs/wood_gib01a.mdl"	"1"
+			"models/gibs/wood_gib01b.mdl"	"1"
+		}
+	}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breakable := "mdlkeyvalue\n{\n\tprop_data\n\t{\n\t\t\"base\"\t\"Wooden.Small\"\n\t}\n}\n"
+	breaks := []phy.Break{
+		{Model: "models/props/crate_chunk01.mdl", Health: 5, Burst: 100},
+		{Model: "models/props/crate_chunk02.mdl", Ragdoll: "models/props/crate_ragdoll.mdl", Debris: true},
+	}
+
+	cases := []struct {
+		name      string
+		keyValues string
+		phy       *phy.Phy
+		noDb      bool
+		// want holds each gib's model, and generic whether they come from db
+		want    []string
+		generic bool
+	}{
+		{"phy breaks win", breakable, &phy.Phy{Breaks: breaks}, false,
+			[]string{"models/props/crate_chunk01.mdl", "models/props/crate_chunk02.mdl"}, false},
+		// Without db the base class is not applied, so the model needs its own health
+		{"phy breaks without db", "prop_data { \"base\" \"Wooden.Small\" \"health\" \"1\" }", &phy.Phy{Breaks: breaks}, true,
+			[]string{"models/props/crate_chunk01.mdl", "models/props/crate_chunk02.mdl"}, false},
+		{"generic gibs", breakable, nil, false,
+			[]string{"models/gibs/wood_gib01a.mdl", "models/gibs/wood_gib01b.mdl"}, true},
+		{"phy without breaks", breakable, &phy.Phy{}, false,
+			[]string{"models/gibs/wood_gib01a.mdl", "models/gibs/wood_gib01b.mdl"}, true},
+		{"generic gibs need db", "prop_data { \"base\" \"Wooden.Small\" \"health\" \"1\" }", nil, true, nil, false},
+		{"generic gibs need a count", "prop_data { \"base\" \"Wooden.Small\" \"breakable_count\" \"0\" }", nil, false, nil, false},
+		{"not breakable", "prop_data { \"base\" \"Wooden.Small\" \"health\" \"0\" }", &phy.Phy{Breaks: breaks}, false, nil, false},
+		{"no prop data", "", &phy.Phy{Breaks: breaks}, false, nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &StudioModel{Mdl: &mdl.Mdl{KeyValues: tc.keyValues}, Phy: tc.phy}
+			database := db
+			if tc.noDb {
+				database = nil
+			}
+
+			gibs, err := model.Gibs(database)
+			if err != nil {
+				t.Fatalf("Gibs failed: %v", err)
+			}
+			if len(gibs) != len(tc.want) {
+				t.Fatalf("got %d gibs (%+v), want %v", len(gibs), gibs, tc.want)
+			}
+			for i, gib := range gibs {
+				if gib.Model != tc.want[i] || gib.Generic != tc.generic || (gib.Break == nil) != tc.generic {
+					t.Errorf("gib %d = %+v, want model %s generic %v", i, gib, tc.want[i], tc.generic)
+				}
+				if tc.generic {
+					if !gib.Debris {
+						t.Errorf("generic gib %d should be debris", i)
+					}
+					continue
+				}
+				piece := &tc.phy.Breaks[i]
+				if gib.Break != piece || gib.Ragdoll != piece.Ragdoll || gib.Health != piece.Health ||
+					gib.Burst != piece.Burst || gib.Debris != piece.Debris {
+					t.Errorf("gib %d = %+v, want it built from %+v", i, gib, piece)
+				}
+			}
+		})
+	}
+
+	if _, err := (&StudioModel{}).Gibs(db); err == nil {
+		t.Error("expected an error for a model without an mdl")
+	}
+}
@@ -0,0 +1,160 @@
+package studiomodel
+
+import (
+	"github.com/galaco/studiomodel/phy"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TraceResult is a hit from a CollisionWorld trace
+type TraceResult struct {
+	// Solid
+	// Index of the hit solid in the phy
+	Solid int
+	// Bone
+	// Bone of the hit solid, -1 if unresolved
+	Bone int
+	// Hull
+	// Index of the hit hull within the solid
+	Hull int
+	// Distance
+	// Distance travelled before the hit, in Source units
+	Distance float32
+	// Position
+	// Position of the trace at the hit. For sphere sweeps this is the sphere centre
+	Position mgl32.Vec3
+	// Normal
+	// Surface normal at the hit. Zero if the trace started inside a solid
+	Normal mgl32.Vec3
+	// StartSolid
+	// Set if the trace started inside a solid
+	StartSolid bool
+}
+
+// collisionHull is a hull of a CollisionWorld with the solid it belongs to
+type collisionHull struct {
+	solid  int
+	bone   int
+	index  int
+	convex *phy.ConvexHull
+}
+
+// CollisionWorld holds a model's collision hulls in model or posed space, prepared for queries
+type CollisionWorld struct {
+	// Solids
+	Solids []CollisionSolid
+	hulls  []collisionHull
+}
+
+// NewCollisionWorld prepares the model's collision for queries.
+// bones holds the bone-to-space transform of every mdl bone; nil uses the bind pose.
+func (model *StudioModel) NewCollisionWorld(bones []mgl32.Mat4) (*CollisionWorld, error) {
+	var solids []CollisionSolid
+	var err error
+	if bones == nil {
+		solids, err = model.CollisionSolids()
+	} else {
+		solids, err = model.PosedCollisionSolids(bones)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newCollisionWorld(solids), nil
+}
+
+// newCollisionWorld prepares every hull of already placed solids
+func newCollisionWorld(solids []CollisionSolid) *CollisionWorld {
+	world := &CollisionWorld{
+		Solids: solids,
+	}
+	for i := range solids {
+		for j := range solids[i].Hulls {
+			world.hulls = append(world.hulls, collisionHull{
+				solid:  solids[i].Index,
+				bone:   solids[i].Bone,
+				index:  j,
+				convex: phy.NewConvexHull(solids[i].Hulls[j]),
+			})
+		}
+	}
+
+	return world
+}
+
+// Raycast returns the nearest hit along a ray. Direction need not be normalised.
+func (world *CollisionWorld) Raycast(origin mgl32.Vec3, direction mgl32.Vec3, maxDistance float32) (TraceResult, bool) {
+	return world.sweep(origin, direction, maxDistance, 0)
+}
+
+// SweepSphere returns the first hit of a sphere moving from start to end
+func (world *CollisionWorld) SweepSphere(start mgl32.Vec3, end mgl32.Vec3, radius float32) (TraceResult, bool) {
+	delta := end.Sub(start)
+	return world.sweep(start, delta, delta.Len(), radius)
+}
+
+// sweep traces a sphere of radius along a direction, returning the nearest hit
+func (world *CollisionWorld) sweep(origin mgl32.Vec3, direction mgl32.Vec3, maxDistance float32, radius float32) (TraceResult, bool) {
+	if direction.Len() == 0 {
+		// A stationary trace only hits what it starts in
+		direction = mgl32.Vec3{0, 0, 1}
+		maxDistance = 0
+	}
+	direction = direction.Normalize()
+	end := origin.Add(direction.Mul(maxDistance))
+
+	best := TraceResult{}
+	found := false
+	for i := range world.hulls {
+		hull := &world.hulls[i]
+		hit, ok := hull.convex.SweepSphere(origin, end, radius)
+		if !ok {
+			continue
+		}
+		distance := hit.Fraction * maxDistance
+		if found && distance >= best.Distance {
+			continue
+		}
+		best = TraceResult{
+			Solid:      hull.solid,
+			Bone:       hull.bone,
+			Hull:       hull.index,
+			Distance:   distance,
+			Position:   origin.Add(direction.Mul(distance)),
+			Normal:     hit.Normal,
+			StartSolid: hit.StartSolid,
+		}
+		found = true
+	}
+
+	return best, found
+}
+
+// ContainingSolids returns the index of every solid that contains a point
+func (world *CollisionWorld) ContainingSolids(point mgl32.Vec3) []int {
+	return world.collect(func(hull *phy.ConvexHull) bool {
+		return hull.Contains(point)
+	})
+}
+
+// OverlappingSolids returns the index of every solid that overlaps an axis aligned box.
+// See phy.ConvexHull.OverlapsAABB for accuracy.
+func (world *CollisionWorld) OverlappingSolids(min mgl32.Vec3, max mgl32.Vec3) []int {
+	return world.collect(func(hull *phy.ConvexHull) bool {
+		return hull.OverlapsAABB(min, max)
+	})
+}
+
+// collect returns the solids with at least one hull matching a test, in order
+func (world *CollisionWorld) collect(test func(hull *phy.ConvexHull) bool) []int {
+	solids := make([]int, 0)
+	for i := range world.hulls {
+		hull := &world.hulls[i]
+		if len(solids) > 0 && solids[len(solids)-1] == hull.solid {
+			continue
+		}
+		if test(hull.convex) {
+			solids = append(solids, hull.solid)
+		}
+	}
+	return solids
+}
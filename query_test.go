@@ -0,0 +1,130 @@
+package studiomodel
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/galaco/studiomodel/phy"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// cubeHull returns an axis aligned cube hull of half size extent around center
+func cubeHull(center mgl32.Vec3, extent float32) phy.Hull {
+	vertices := make([]mgl32.Vec3, 8)
+	for i := range vertices {
+		for axis := 0; axis < 3; axis++ {
+			vertices[i][axis] = center[axis] - extent
+			if i&(1<<axis) != 0 {
+				vertices[i][axis] = center[axis] + extent
+			}
+		}
+	}
+	return phy.Hull{
+		Vertices: vertices,
+		Triangles: [][3]uint16{
+			{0, 2, 3}, {0, 3, 1}, {4, 5, 7}, {4, 7, 6},
+			{0, 1, 5}, {0, 5, 4}, {2, 6, 7}, {2, 7, 3},
+			{0, 4, 6}, {0, 6, 2}, {1, 3, 7}, {1, 7, 5},
+		},
+	}
+}
+
+// testWorld has a solid of one cube at x=0 and a solid of two cubes at x=10 and x=20
+func testWorld() *CollisionWorld {
+	return newCollisionWorld([]CollisionSolid{
+		{Index: 0, Bone: 3, Hulls: []phy.Hull{cubeHull(mgl32.Vec3{0, 0, 0}, 1)}},
+		{Index: 1, Bone: -1, Hulls: []phy.Hull{cubeHull(mgl32.Vec3{10, 0, 0}, 1), cubeHull(mgl32.Vec3{20, 0, 0}, 1)}},
+	})
+}
+
+func TestCollisionWorldRaycast(t *testing.T) {
+	world := testWorld()
+
+	cases := []struct {
+		name      string
+		origin    mgl32.Vec3
+		direction mgl32.Vec3
+		distance  float32
+		hit       bool
+		want      TraceResult
+	}{
+		{"nearest solid", mgl32.Vec3{-5, 0, 0}, mgl32.Vec3{2, 0, 0}, 100, true,
+			TraceResult{Solid: 0, Bone: 3, Hull: 0, Distance: 4, Position: mgl32.Vec3{-1, 0, 0}, Normal: mgl32.Vec3{-1, 0, 0}}},
+		{"second hull", mgl32.Vec3{25, 0, 0}, mgl32.Vec3{-1, 0, 0}, 100, true,
+			TraceResult{Solid: 1, Bone: -1, Hull: 1, Distance: 4, Position: mgl32.Vec3{21, 0, 0}, Normal: mgl32.Vec3{1, 0, 0}}},
+		{"between solids", mgl32.Vec3{5, 0, 0}, mgl32.Vec3{1, 0, 0}, 100, true,
+			TraceResult{Solid: 1, Bone: -1, Hull: 0, Distance: 4, Position: mgl32.Vec3{9, 0, 0}, Normal: mgl32.Vec3{-1, 0, 0}}},
+		{"too short", mgl32.Vec3{-5, 0, 0}, mgl32.Vec3{1, 0, 0}, 3, false, TraceResult{}},
+		{"misses", mgl32.Vec3{-5, 5, 0}, mgl32.Vec3{1, 0, 0}, 100, false, TraceResult{}},
+		{"starts inside", mgl32.Vec3{10, 0, 0}, mgl32.Vec3{0, 1, 0}, 100, true,
+			TraceResult{Solid: 1, Bone: -1, Hull: 0, Position: mgl32.Vec3{10, 0, 0}, StartSolid: true}},
+		{"no direction inside", mgl32.Vec3{0, 0, 0}, mgl32.Vec3{}, 100, true,
+			TraceResult{Solid: 0, Bone: 3, Hull: 0, StartSolid: true}},
+		{"no direction outside", mgl32.Vec3{5, 0, 0}, mgl32.Vec3{}, 100, false, TraceResult{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := world.Raycast(tc.origin, tc.direction, tc.distance)
+			if ok != tc.hit {
+				t.Fatalf("hit = %v, want %v (%+v)", ok, tc.hit, got)
+			}
+			if !ok {
+				return
+			}
+			if got.Solid != tc.want.Solid || got.Bone != tc.want.Bone || got.Hull != tc.want.Hull ||
+				got.StartSolid != tc.want.StartSolid || !mgl32.FloatEqualThreshold(got.Distance, tc.want.Distance, 1e-4) ||
+				!got.Position.ApproxEqualThreshold(tc.want.Position, 1e-4) || !got.Normal.ApproxEqualThreshold(tc.want.Normal, 1e-4) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollisionWorldSweepSphere(t *testing.T) {
+	world := testWorld()
+
+	got, ok := world.SweepSphere(mgl32.Vec3{5, 0, 0}, mgl32.Vec3{-5, 0, 0}, 0.5)
+	if !ok || got.Solid != 0 || !mgl32.FloatEqualThreshold(got.Distance, 3.5, 1e-4) ||
+		!got.Position.ApproxEqualThreshold(mgl32.Vec3{1.5, 0, 0}, 1e-4) {
+		t.Errorf("sweep = %+v, %v, want solid 0 stopping at x=1.5", got, ok)
+	}
+
+	// The sphere passes 1.4 units from the cubes' faces
+	if got, ok := world.SweepSphere(mgl32.Vec3{-5, 2.4, 0}, mgl32.Vec3{25, 2.4, 0}, 1); ok {
+		t.Errorf("sweep hit %+v, want a miss", got)
+	}
+}
+
+func TestCollisionWorldQueries(t *testing.T) {
+	world := testWorld()
+
+	containing := []struct {
+		point mgl32.Vec3
+		want  []int
+	}{
+		{mgl32.Vec3{0, 0, 0}, []int{0}},
+		{mgl32.Vec3{20.5, 0.5, 0.5}, []int{1}},
+		{mgl32.Vec3{15, 0, 0}, []int{}},
+	}
+	for _, tc := range containing {
+		if got := world.ContainingSolids(tc.point); !slices.Equal(got, tc.want) {
+			t.Errorf("ContainingSolids(%v) = %v, want %v", tc.point, got, tc.want)
+		}
+	}
+
+	overlapping := []struct {
+		min, max mgl32.Vec3
+		want     []int
+	}{
+		// Both hulls of solid 1 overlap, but it is only reported once
+		{mgl32.Vec3{-2, -2, -2}, mgl32.Vec3{22, 2, 2}, []int{0, 1}},
+		{mgl32.Vec3{9, -2, -2}, mgl32.Vec3{22, 2, 2}, []int{1}},
+		{mgl32.Vec3{2, -2, -2}, mgl32.Vec3{8, 2, 2}, []int{}},
+	}
+	for _, tc := range overlapping {
+		if got := world.OverlappingSolids(tc.min, tc.max); !slices.Equal(got, tc.want) {
+			t.Errorf("OverlappingSolids(%v, %v) = %v, want %v", tc.min, tc.max, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package studiomodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/galaco/studiomodel/phy"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// RagdollJoint is a ragdoll constraint joined with the solids and bones it connects.
+// Limits are in degrees, around the X, Y and Z axes of the child bone.
+type RagdollJoint struct {
+	// Parent
+	// Index of the parent solid
+	Parent int
+	// Child
+	// Index of the child solid
+	Child int
+	// ParentBone
+	// Index of the parent solid's bone, -1 if unresolved
+	ParentBone int
+	// ChildBone
+	// Index of the child solid's bone, -1 if unresolved
+	ChildBone int
+	// ParentFrame
+	// Joint frame relative to the parent bone. The joint sits at the child bone's origin
+	ParentFrame mgl32.Mat4
+	// ChildFrame
+	// Joint frame relative to the child bone. Always the identity, as constraints are
+	// expressed in the child's space
+	ChildFrame mgl32.Mat4
+	// Min
+	Min mgl32.Vec3
+	// Max
+	Max mgl32.Vec3
+	// Friction
+	Friction mgl32.Vec3
+	// Constraint
+	// The constraint block this joint was built from
+	Constraint *phy.RagdollConstraint
+}
+
+// RagdollJoints returns every ragdoll constraint of the model, with joint frames in the bind pose
+func (model *StudioModel) RagdollJoints() ([]RagdollJoint, error) {
+	if model.Phy == nil {
+		return nil, errors.New("model has no collision model")
+	}
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to resolve ragdoll joints")
+	}
+
+	bindPose := model.Mdl.BindPose()
+	joints := make([]RagdollJoint, 0, len(model.Phy.RagdollConstraints))
+	for i := range model.Phy.RagdollConstraints {
+		constraint := &model.Phy.RagdollConstraints[i]
+		if constraint.Parent < 0 || constraint.Parent >= len(model.Phy.Surfaces) ||
+			constraint.Child < 0 || constraint.Child >= len(model.Phy.Surfaces) {
+			return nil, fmt.Errorf("ragdoll constraint %d references solids %d and %d (have %d solids)",
+				i, constraint.Parent, constraint.Child, len(model.Phy.Surfaces))
+		}
+
+		joint := RagdollJoint{
+			Parent:      constraint.Parent,
+			Child:       constraint.Child,
+			ParentBone:  model.resolveSolid(constraint.Parent).Bone,
+			ChildBone:   model.resolveSolid(constraint.Child).Bone,
+			ParentFrame: mgl32.Ident4(),
+			ChildFrame:  mgl32.Ident4(),
+			Min:         mgl32.Vec3{constraint.XMin, constraint.YMin, constraint.ZMin},
+			Max:         mgl32.Vec3{constraint.XMax, constraint.YMax, constraint.ZMax},
+			Friction:    mgl32.Vec3{constraint.XFriction, constraint.YFriction, constraint.ZFriction},
+			Constraint:  constraint,
+		}
+		if joint.ParentBone >= 0 && joint.ChildBone >= 0 {
+			joint.ParentFrame = bindPose[joint.ParentBone].Inv().Mul4(bindPose[joint.ChildBone])
+		}
+
+		joints = append(joints, joint)
+	}
+
+	return joints, nil
+}
+
+// PhysicsDescription is an engine neutral description of a model's rigid bodies and joints.
+// Positions are in Source units; matrices are column-major, as in mgl32.
+type PhysicsDescription struct {
+	// Bodies
+	Bodies []PhysicsBody `json:"bodies"`
+	// Joints
+	Joints []PhysicsJoint `json:"joints"`
+	// SelfCollisions
+	// When false bodies of the model never collide with each other
+	SelfCollisions bool `json:"selfCollisions"`
+	// CollisionPairs
+	// Pairs of body indices allowed to collide when SelfCollisions is false
+	CollisionPairs [][2]int `json:"collisionPairs,omitempty"`
+}
+
+// PhysicsBody is a single rigid body
+type PhysicsBody struct {
+	// Name
+	// Name of the bone the body follows
+	Name string `json:"name"`
+	// Bone
+	Bone int `json:"bone"`
+	// Transform
+	// Bone to model transform in the bind pose
+	Transform [16]float32 `json:"transform"`
+	// Mass
+	Mass float32 `json:"mass"`
+	// SurfaceProp
+	SurfaceProp string `json:"surfaceProp,omitempty"`
+	// Damping
+	Damping float32 `json:"damping"`
+	// RotDamping
+	RotDamping float32 `json:"rotDamping"`
+	// Shapes
+	// Convex hulls relative to the body transform
+	Shapes []PhysicsShape `json:"shapes"`
+}
+
+// PhysicsShape is a convex hull
+type PhysicsShape struct {
+	// Vertices
+	Vertices [][3]float32 `json:"vertices"`
+	// Triangles
+	Triangles [][3]uint16 `json:"triangles"`
+}
+
+// PhysicsJoint is a ragdoll joint between two bodies
+type PhysicsJoint struct {
+	// Parent
+	// Index into Bodies
+	Parent int `json:"parent"`
+	// Child
+	// Index into Bodies
+	Child int `json:"child"`
+	// ParentFrame
+	// Joint frame relative to the parent body
+	ParentFrame [16]float32 `json:"parentFrame"`
+	// ChildFrame
+	// Joint frame relative to the child body
+	ChildFrame [16]float32 `json:"childFrame"`
+	// Min
+	// Lower rotation limits around X, Y and Z, in degrees
+	Min [3]float32 `json:"min"`
+	// Max
+	// Upper rotation limits around X, Y and Z, in degrees
+	Max [3]float32 `json:"max"`
+	// Friction
+	Friction [3]float32 `json:"friction"`
+}
+
+// PhysicsDescription builds the engine neutral description of the model's physics
+func (model *StudioModel) PhysicsDescription() (*PhysicsDescription, error) {
+	joints, err := model.RagdollJoints()
+	if err != nil {
+		return nil, err
+	}
+
+	bindPose := model.Mdl.BindPose()
+	description := &PhysicsDescription{
+		Bodies:         make([]PhysicsBody, len(model.Phy.Surfaces)),
+		Joints:         make([]PhysicsJoint, len(joints)),
+		SelfCollisions: true,
+	}
+
+	for i := range model.Phy.Surfaces {
+		solid := model.resolveSolid(i)
+		body := PhysicsBody{
+			Name:      solid.BoneName,
+			Bone:      solid.Bone,
+			Transform: mgl32.Ident4(),
+		}
+		if solid.Bone >= 0 {
+			body.Transform = bindPose[solid.Bone]
+		}
+		if solid.Properties != nil {
+			body.Mass = solid.Properties.Mass
+			body.SurfaceProp = solid.Properties.SurfaceProp
+			body.Damping = solid.Properties.Damping
+			body.RotDamping = solid.Properties.RotDamping
+		}
+
+		for _, hull := range model.Phy.Surfaces[i].Hulls() {
+			shape := PhysicsShape{
+				Vertices:  make([][3]float32, len(hull.Vertices)),
+				Triangles: hull.Triangles,
+			}
+			for j, v := range hull.Vertices {
+				shape.Vertices[j] = phy.ToSourceSpace(v)
+			}
+			body.Shapes = append(body.Shapes, shape)
+		}
+
+		description.Bodies[i] = body
+	}
+
+	for i, joint := range joints {
+		description.Joints[i] = PhysicsJoint{
+			Parent:      joint.Parent,
+			Child:       joint.Child,
+			ParentFrame: joint.ParentFrame,
+			ChildFrame:  joint.ChildFrame,
+			Min:         joint.Min,
+			Max:         joint.Max,
+			Friction:    joint.Friction,
+		}
+	}
+
+	if rules := model.Phy.CollisionRules; rules != nil {
+		description.SelfCollisions = rules.SelfCollisions
+		description.CollisionPairs = rules.CollisionPairs
+	}
+
+	return description, nil
+}
+
+// WritePhysicsJSON writes the model's PhysicsDescription as JSON
+func (model *StudioModel) WritePhysicsJSON(w io.Writer) error {
+	description, err := model.PhysicsDescription()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(description)
+}
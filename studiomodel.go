@@ -19,6 +19,16 @@ type StudioModel struct {
 	Vtx *vtx.Vtx
 	// Phy
 	Phy *phy.Phy
+	// Ani
+	// Raw external animation data (the .ani named by Mdl.AnimBlockName), nil if not loaded
+	Ani []byte
+	// Missing
+	// Optional files that were not found by Load
+	Missing []string
+	// PhyErr
+	// Error from reading the phy found by Load, or from its checksum not matching the mdl.
+	// The model is still returned without collision when it is set
+	PhyErr error
 }
 
 // HasCollisionModel
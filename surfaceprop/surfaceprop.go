@@ -0,0 +1,270 @@
+package surfaceprop
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/galaco/studiomodel/internal"
+)
+
+const (
+	// DefaultManifestPath is where the engine looks for the surface properties manifest
+	DefaultManifestPath = "scripts/surfaceproperties_manifest.txt"
+	// DefaultName is the surface prop every other one implicitly inherits from
+	DefaultName = "default"
+	// cubicInchesToCubicMeters converts Source volumes to the units densities are defined in
+	cubicInchesToCubicMeters = 0.0254 * 0.0254 * 0.0254
+)
+
+// Property is a surface property with its base chain resolved
+type Property struct {
+	// Name
+	Name string
+	// Base
+	// Name of the surface prop this one inherits from, if any
+	Base string
+	// Density
+	// kg/m³
+	Density float32
+	// Friction
+	Friction float32
+	// Elasticity
+	Elasticity float32
+	// Thickness
+	// Non zero for hollow objects, in inches
+	Thickness float32
+	// Dampening
+	Dampening float32
+	// JumpFactor
+	JumpFactor float32
+	// MaxSpeedFactor
+	MaxSpeedFactor float32
+	// GameMaterial
+	// Single character material type used by game code (e.g. "M" for metal)
+	GameMaterial string
+	// Sounds
+	// Sound names keyed by lowercased key, e.g. "stepleft", "impacthard", "bulletimpact"
+	Sounds map[string]string
+	// Values holds every resolved key, lowercased
+	Values map[string]string
+}
+
+// MassForVolume returns the mass in kg of a solid of this material.
+// Volume is in cubic inches. Hollow props (non zero Thickness) need surface area instead,
+// and are not handled here.
+func (prop *Property) MassForVolume(volume float32) float32 {
+	return prop.Density * volume * cubicInchesToCubicMeters
+}
+
+// definition is a surface prop as written in a script, before inheritance
+type definition struct {
+	name   string
+	values map[string]string
+}
+
+// Database is a set of surface properties parsed from one or more scripts
+type Database struct {
+	definitions map[string]*definition
+}
+
+// NewDatabase returns an empty Database
+func NewDatabase() *Database {
+	return &Database{
+		definitions: make(map[string]*definition),
+	}
+}
+
+// AddScript parses a surfaceproperties script and adds its definitions.
+// As in the engine, the first definition of a name wins.
+func (db *Database) AddScript(stream io.Reader) error {
+	text, err := readText(stream)
+	if err != nil {
+		return err
+	}
+	nodes, err := internal.ParseKeyValues(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse surface properties: %w", err)
+	}
+
+	for _, node := range nodes {
+		if !node.IsBlock {
+			continue
+		}
+		key := strings.ToLower(node.Key)
+		if _, ok := db.definitions[key]; ok {
+			continue
+		}
+		db.definitions[key] = &definition{
+			name:   node.Key,
+			values: node.Values(),
+		}
+	}
+
+	return nil
+}
+
+// Names returns the name of every defined surface prop, sorted
+func (db *Database) Names() []string {
+	names := make([]string, 0, len(db.definitions))
+	for _, def := range db.definitions {
+		names = append(names, def.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns a surface prop with its base chain resolved. Names are compared case-insensitively.
+// Props without a "base" inherit from DefaultName, as in the engine.
+func (db *Database) Get(name string) (*Property, error) {
+	values, err := db.resolve(strings.ToLower(name), make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	def := db.definitions[strings.ToLower(name)]
+	prop := &Property{
+		Name:           def.name,
+		Base:           def.values["base"],
+		Density:        parseFloat(values["density"]),
+		Friction:       parseFloat(values["friction"]),
+		Elasticity:     parseFloat(values["elasticity"]),
+		Thickness:      parseFloat(values["thickness"]),
+		Dampening:      parseFloat(values["dampening"]),
+		JumpFactor:     parseFloat(values["jumpfactor"]),
+		MaxSpeedFactor: parseFloat(values["maxspeedfactor"]),
+		GameMaterial:   values["gamematerial"],
+		Sounds:         make(map[string]string),
+		Values:         values,
+	}
+	for key, value := range values {
+		if strings.HasPrefix(key, "step") || strings.HasPrefix(key, "impact") ||
+			strings.HasPrefix(key, "scrape") || strings.HasPrefix(key, "bulletimpact") ||
+			key == "rolling" || key == "break" || key == "strain" {
+			prop.Sounds[key] = value
+		}
+	}
+
+	return prop, nil
+}
+
+// GetOrDefault returns a surface prop, falling back to DefaultName when it is not defined
+func (db *Database) GetOrDefault(name string) (*Property, error) {
+	if _, ok := db.definitions[strings.ToLower(name)]; !ok {
+		name = DefaultName
+	}
+	return db.Get(name)
+}
+
+// resolve merges a definition over its base chain
+func (db *Database) resolve(name string, visited map[string]bool) (map[string]string, error) {
+	def, ok := db.definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("surface prop %q is not defined", name)
+	}
+	if visited[name] {
+		return nil, fmt.Errorf("surface prop %q inherits from itself", def.name)
+	}
+	visited[name] = true
+
+	base := strings.ToLower(def.values["base"])
+	if base == "" && name != DefaultName {
+		if _, ok := db.definitions[DefaultName]; ok {
+			base = DefaultName
+		}
+	}
+
+	values := make(map[string]string)
+	if base != "" {
+		inherited, err := db.resolve(base, visited)
+		if err != nil {
+			return nil, fmt.Errorf("base of %q: %w", def.name, err)
+		}
+		for key, value := range inherited {
+			values[key] = value
+		}
+	}
+	for key, value := range def.values {
+		values[key] = value
+	}
+	delete(values, "base")
+
+	return values, nil
+}
+
+// ParseManifest returns the script paths listed in a surfaceproperties manifest, in order
+func ParseManifest(stream io.Reader) ([]string, error) {
+	text, err := readText(stream)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := internal.ParseKeyValues(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse surface properties manifest: %w", err)
+	}
+
+	files := make([]string, 0)
+	for _, node := range nodes {
+		if !node.IsBlock {
+			continue
+		}
+		for _, file := range node.FindAll("file") {
+			if !file.IsBlock {
+				files = append(files, strings.ReplaceAll(file.Value, `\`, "/"))
+			}
+		}
+	}
+	return files, nil
+}
+
+// LoadFromFS reads a manifest and every script it lists. Script paths are relative to the
+// root of fsys, as in the engine.
+func LoadFromFS(fsys fs.FS, manifestPath string) (*Database, error) {
+	manifest, err := fsys.Open(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ParseManifest(manifest)
+	manifest.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	db := NewDatabase()
+	for _, file := range files {
+		script, err := fsys.Open(path.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open surface properties script %s: %w", file, err)
+		}
+		err = db.AddScript(script)
+		script.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return db, nil
+}
+
+// readText reads a whole stream as text
+func readText(stream io.Reader) (string, error) {
+	buf := bytes.Buffer{}
+	if _, err := buf.ReadFrom(stream); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseFloat parses a float value, 0 if malformed
+func parseFloat(value string) float32 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+	if err != nil {
+		return 0
+	}
+	return float32(f)
+}
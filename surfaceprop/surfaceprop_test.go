@@ -0,0 +1,237 @@
+package surfaceprop
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const baseScript = `// Base surface properties
+"default"
+{
+	"density"	"2000"
+	"friction"	"0.8"
+	"elasticity"	"0.25"
+	"gamematerial"	"C"
+	"stepleft"	"Default.StepLeft"
+	"impacthard"	"Default.ImpactHard"
+}
+
+"Metal"
+{
+	"density"	"2700"
+	"gamematerial"	"M"
+	"bulletimpact"	"Metal.BulletImpact"
+}
+
+"metal_box"
+{
+	"base"	"metal"
+	"thickness"	"0.5"
+}
+
+"loop_a"
+{
+	"base"	"loop_b"
+}
+
+"loop_b"
+{
+	"base"	"LOOP_A"
+}
+
+"missing_base"
+{
+	"base"	"unobtainium"
+}
+`
+
+const gameScript = `"metal"
+{
+	"density"	"7800"
+	"gamematerial"	"M"
+}
+
+"flesh"
+{
+	"base"	"default"
+	"density"	"900"
+	"gamematerial"	"F"
+}
+`
+
+// testFS returns a manifest listing the game script then the base script, or the reverse
+func testFS(gameFirst bool) fstest.MapFS {
+	files := []string{`scripts\surfaceproperties_game.txt`, "scripts/surfaceproperties.txt"}
+	if !gameFirst {
+		slices.Reverse(files)
+	}
+	manifest := "surfaceproperties_manifest\n{\n"
+	for _, file := range files {
+		manifest += "\t\"file\"\t\"" + file + "\"\n"
+	}
+	manifest += "}\n"
+
+	return fstest.MapFS{
+		DefaultManifestPath:                  {Data: []byte(manifest)},
+		"scripts/surfaceproperties.txt":      {Data: []byte(baseScript)},
+		"scripts/surfaceproperties_game.txt": {Data: []byte(gameScript)},
+	}
+}
+
+func TestDatabaseGet(t *testing.T) {
+	db, err := LoadFromFS(testFS(false), DefaultManifestPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	cases := []struct {
+		name         string
+		prop         string
+		wantName     string
+		base         string
+		density      float32
+		friction     float32
+		thickness    float32
+		gameMaterial string
+		sounds       map[string]string
+	}{
+		{
+			name: "default", prop: "default", wantName: "default", density: 2000, friction: 0.8, gameMaterial: "C",
+			sounds: map[string]string{"stepleft": "Default.StepLeft", "impacthard": "Default.ImpactHard"},
+		},
+		{
+			// Without a base, metal still picks up default's friction and sounds
+			name: "implicit default base", prop: "metal", wantName: "Metal", density: 2700, friction: 0.8, gameMaterial: "M",
+			sounds: map[string]string{"stepleft": "Default.StepLeft", "impacthard": "Default.ImpactHard", "bulletimpact": "Metal.BulletImpact"},
+		},
+		{
+			name: "explicit base", prop: "metal_box", wantName: "metal_box", base: "metal", density: 2700, friction: 0.8,
+			thickness: 0.5, gameMaterial: "M",
+			sounds: map[string]string{"stepleft": "Default.StepLeft", "impacthard": "Default.ImpactHard", "bulletimpact": "Metal.BulletImpact"},
+		},
+		{
+			name: "case insensitive", prop: "METAL_Box", wantName: "metal_box", base: "metal", density: 2700, friction: 0.8,
+			thickness: 0.5, gameMaterial: "M",
+			sounds: map[string]string{"stepleft": "Default.StepLeft", "impacthard": "Default.ImpactHard", "bulletimpact": "Metal.BulletImpact"},
+		},
+		{
+			name: "later script", prop: "Flesh", wantName: "flesh", base: "default", density: 900, friction: 0.8, gameMaterial: "F",
+			sounds: map[string]string{"stepleft": "Default.StepLeft", "impacthard": "Default.ImpactHard"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			prop, err := db.Get(tc.prop)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if prop.Name != tc.wantName || prop.Base != tc.base || prop.Density != tc.density || prop.Friction != tc.friction ||
+				prop.Thickness != tc.thickness || prop.GameMaterial != tc.gameMaterial {
+				t.Errorf("prop = %+v", prop)
+			}
+			if len(prop.Sounds) != len(tc.sounds) {
+				t.Errorf("sounds = %v, want %v", prop.Sounds, tc.sounds)
+			}
+			for key, sound := range tc.sounds {
+				if prop.Sounds[key] != sound {
+					t.Errorf("sound %s = %q, want %q", key, prop.Sounds[key], sound)
+				}
+			}
+			if _, ok := prop.Values["base"]; ok {
+				t.Error("resolved values should not include base")
+			}
+		})
+	}
+}
+
+func TestDatabaseGetErrors(t *testing.T) {
+	db, err := LoadFromFS(testFS(false), DefaultManifestPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		prop string
+		want string
+	}{
+		{"undefined", "glass", `surface prop "glass" is not defined`},
+		{"inheritance cycle", "loop_a", `surface prop "loop_a" inherits from itself`},
+		{"cycle from the other end", "LOOP_B", `surface prop "loop_b" inherits from itself`},
+		{"undefined base", "missing_base", `base of "missing_base": surface prop "unobtainium" is not defined`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := db.Get(tc.prop); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	prop, err := db.GetOrDefault("glass")
+	if err != nil || prop.Name != DefaultName {
+		t.Errorf("GetOrDefault = %+v, %v, want the default prop", prop, err)
+	}
+}
+
+func TestLoadFromFSManifestOrder(t *testing.T) {
+	cases := []struct {
+		name      string
+		gameFirst bool
+		// density is metal's, which both scripts define
+		density float32
+	}{
+		{"base script first", false, 2700},
+		{"game script first", true, 7800},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := LoadFromFS(testFS(tc.gameFirst), DefaultManifestPath)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			metal, err := db.Get("metal")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metal.Density != tc.density {
+				t.Errorf("metal density = %v, want %v from the first script", metal.Density, tc.density)
+			}
+
+			want := []string{"default", "flesh", "loop_a", "loop_b", "metal_box", "missing_base"}
+			if tc.gameFirst {
+				want = append(want, "metal")
+			} else {
+				want = append(want, "Metal")
+			}
+			slices.Sort(want)
+			if names := db.Names(); !slices.Equal(names, want) {
+				t.Errorf("names = %v, want %v", names, want)
+			}
+		})
+	}
+}
+
+func TestLoadFromFSErrors(t *testing.T) {
+	files := testFS(false)
+	delete(files, "scripts/surfaceproperties_game.txt")
+	if _, err := LoadFromFS(files, DefaultManifestPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing script error = %v, want fs.ErrNotExist", err)
+	}
+
+	if _, err := LoadFromFS(files, "scripts/missing_manifest.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing manifest error = %v, want fs.ErrNotExist", err)
+	}
+
+	files = testFS(false)
+	files["scripts/surfaceproperties.txt"] = &fstest.MapFile{Data: []byte(`"default" { "density" "1"`)}
+	if _, err := LoadFromFS(files, DefaultManifestPath); err == nil || !strings.Contains(err.Error(), "scripts/surfaceproperties.txt") {
+		t.Errorf("malformed script error = %v, want it to name the script", err)
+	}
+}
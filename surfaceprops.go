@@ -0,0 +1,32 @@
+package studiomodel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/galaco/studiomodel/surfaceprop"
+)
+
+// SurfaceProperties resolves the model's surface prop ($surfaceprop) against a database.
+// Models without one use surfaceprop.DefaultName.
+func (model *StudioModel) SurfaceProperties(db *surfaceprop.Database) (*surfaceprop.Property, error) {
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to resolve surface properties")
+	}
+	return db.GetOrDefault(model.Mdl.SurfaceProp)
+}
+
+// BoneSurfaceProperties resolves a bone's surface prop against a database.
+// Bones without one use the model's surface prop.
+func (model *StudioModel) BoneSurfaceProperties(db *surfaceprop.Database, bone int) (*surfaceprop.Property, error) {
+	if model.Mdl == nil {
+		return nil, errors.New("model requires an mdl to resolve surface properties")
+	}
+	if bone < 0 || bone >= len(model.Mdl.BoneSurfaceProps) {
+		return nil, fmt.Errorf("bone %d out of range (have %d bones)", bone, len(model.Mdl.BoneSurfaceProps))
+	}
+	if model.Mdl.BoneSurfaceProps[bone] == "" {
+		return model.SurfaceProperties(db)
+	}
+	return db.GetOrDefault(model.Mdl.BoneSurfaceProps[bone])
+}
@@ -0,0 +1,112 @@
+package studiomodel
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/galaco/studiomodel/vvd"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MeshVertexRange returns the first vertex and vertex count of a mesh within the vertex
+// array for the given LOD.
+// For models with a fixup table the LOD array is the one produced by applying the fixups,
+// otherwise it is Vvd.Vertices.
+func (model *StudioModel) MeshVertexRange(bodyPart, subModel, mesh, lod int) (start int, count int, err error) {
+	if model.Mdl == nil || model.Vvd == nil {
+		return 0, 0, errors.New("model requires both an mdl and a vvd to resolve vertices")
+	}
+	if lod < 0 || lod >= int(model.Vvd.Header.NumLODs) {
+		return 0, 0, fmt.Errorf("lod %d out of range (have %d lods)", lod, model.Vvd.Header.NumLODs)
+	}
+	if _, err := model.Mdl.GetMaterialIndexForMesh(bodyPart, subModel, mesh); err != nil {
+		return 0, 0, err
+	}
+
+	// Without fixups every LOD shares one array, addressed through the mdl offsets
+	if len(model.Vvd.Fixups) == 0 {
+		modelData := &model.Mdl.BodyParts[bodyPart].Models[subModel]
+		meshHeader := &modelData.Meshes[mesh]
+		start = int(modelData.Header.VertexIndex)/int(unsafe.Sizeof(vvd.Vertex{})) + int(meshHeader.VertexOffset)
+		return start, int(meshHeader.NumVertices), nil
+	}
+
+	// Fixups lay out each mesh's vertices for a LOD contiguously, in hierarchy order
+	for i := range model.Mdl.BodyParts {
+		for j := range model.Mdl.BodyParts[i].Models {
+			for k, meshHeader := range model.Mdl.BodyParts[i].Models[j].Meshes {
+				if i == bodyPart && j == subModel && k == mesh {
+					return start, int(meshHeader.NumLODVertexes[lod]), nil
+				}
+				start += int(meshHeader.NumLODVertexes[lod])
+			}
+		}
+	}
+
+	return 0, 0, fmt.Errorf("mesh %d of model %d in body part %d not found", mesh, subModel, bodyPart)
+}
+
+// MeshVertices returns the vertices and tangents a mesh uses at the given LOD.
+// VTX OriginalMeshVertexID values index directly into the returned slices.
+// Tangents is nil if the vvd contains no tangent data.
+func (model *StudioModel) MeshVertices(bodyPart, subModel, mesh, lod int) ([]vvd.Vertex, []mgl32.Vec4, error) {
+	start, count, err := model.MeshVertexRange(bodyPart, subModel, mesh, lod)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vertices, err := model.Vvd.VerticesForLOD(lod)
+	if err != nil {
+		return nil, nil, err
+	}
+	tangents, err := model.Vvd.TangentsForLOD(lod)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	end := start + count
+	if start < 0 || end > len(vertices) {
+		return nil, nil, fmt.Errorf("mesh vertices [%d:%d] out of range (lod %d has %d vertices)", start, end, lod, len(vertices))
+	}
+
+	var meshTangents []mgl32.Vec4
+	if end <= len(tangents) {
+		meshTangents = tangents[start:end]
+	}
+
+	return vertices[start:end], meshTangents, nil
+}
+
+// MeshTriangles returns a mesh's triangles at a LOD as indices into the vertex array for
+// that LOD (see MeshVertexRange), ready to pair with Vvd.VerticesForLOD.
+func (model *StudioModel) MeshTriangles(bodyPart, subModel, mesh, lod int) ([][3]uint32, error) {
+	if model.Vtx == nil {
+		return nil, errors.New("model requires a vtx to resolve triangles")
+	}
+	start, count, err := model.MeshVertexRange(bodyPart, subModel, mesh, lod)
+	if err != nil {
+		return nil, err
+	}
+
+	if bodyPart >= len(model.Vtx.BodyParts) ||
+		subModel >= len(model.Vtx.BodyParts[bodyPart].Models) ||
+		lod >= len(model.Vtx.BodyParts[bodyPart].Models[subModel].LODS) ||
+		mesh >= len(model.Vtx.BodyParts[bodyPart].Models[subModel].LODS[lod].Meshes) {
+		return nil, fmt.Errorf("vtx has no mesh %d for model %d in body part %d at lod %d", mesh, subModel, bodyPart, lod)
+	}
+	vtxMesh := &model.Vtx.BodyParts[bodyPart].Models[subModel].LODS[lod].Meshes[mesh]
+
+	local := vtxMesh.Triangles()
+	triangles := make([][3]uint32, len(local))
+	for i, triangle := range local {
+		for j, index := range triangle {
+			if int(index) >= count {
+				return nil, fmt.Errorf("triangle %d references mesh vertex %d (mesh has %d vertices)", i, index, count)
+			}
+			triangles[i][j] = uint32(start) + uint32(index)
+		}
+	}
+
+	return triangles, nil
+}
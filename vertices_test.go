@@ -0,0 +1,195 @@
+package studiomodel
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/galaco/studiomodel/mdl"
+	"github.com/galaco/studiomodel/vtx"
+	"github.com/galaco/studiomodel/vvd"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// triangleListGroup returns a strip group without strips, whose vertexes map straight
+// onto mesh vertex IDs
+func triangleListGroup(numVertices int, indices ...uint16) vtx.StripGroup {
+	group := vtx.StripGroup{Indices: indices, Vertexes: make([]vtx.Vertex, numVertices)}
+	for i := range group.Vertexes {
+		group.Vertexes[i].OriginalMeshVertexID = uint16(i)
+	}
+	return group
+}
+
+// vertexTestModel is a single model with two meshes over two LODs. Mesh 0 has 4 vertices
+// at LOD 0 and 3 at LOD 1, mesh 1 has 3 at both. Every vertex's X is its index in
+// Vvd.Vertices.
+//
+// With fixups the vvd stores exactly those 7 vertices, with the vertex mesh 0 drops at
+// LOD 1 last in its run. Without fixups the model's vertices start 2 vertices into a
+// shared 9 vertex array and both LODs use all of them.
+func vertexTestModel(fixups bool) *StudioModel {
+	meshes := []mdl.Mesh{
+		{Material: 0, NumVertices: 4, VertexOffset: 0, NumLODVertexes: [8]int32{4, 3}},
+		{Material: 1, NumVertices: 3, VertexOffset: 4, NumLODVertexes: [8]int32{3, 3}},
+	}
+	modelHeader := mdl.Model{NumMeshes: 2, NumVertices: 7}
+
+	numVertices := 7
+	vvdFile := &vvd.Vvd{Header: vvd.Header{NumLODs: 2, NumLODVertexes: [vvd.MaxNumLods]int32{7, 6}}}
+	if fixups {
+		vvdFile.Fixups = []vvd.Fixup{
+			{Lod: 1, SourceVertexID: 0, NumVertexes: 3},
+			{Lod: 0, SourceVertexID: 3, NumVertexes: 1},
+			{Lod: 1, SourceVertexID: 4, NumVertexes: 3},
+		}
+	} else {
+		numVertices = 9
+		modelHeader.VertexIndex = 2 * int32(unsafe.Sizeof(vvd.Vertex{}))
+	}
+	for i := 0; i < numVertices; i++ {
+		vvdFile.Vertices = append(vvdFile.Vertices, vvd.NewVertex(mgl32.Vec3{float32(i)}, mgl32.Vec3{0, 0, 1}, mgl32.Vec2{}, vvd.NewRigidBoneWeight(0)))
+		vvdFile.Tangents = append(vvdFile.Tangents, mgl32.Vec4{float32(i), 0, 0, 1})
+	}
+
+	vtxModel := vtx.Model{LODS: []vtx.ModelLOD{
+		{Meshes: []vtx.Mesh{
+			{StripGroups: []vtx.StripGroup{triangleListGroup(4, 0, 1, 2, 0, 2, 3)}},
+			{StripGroups: []vtx.StripGroup{triangleListGroup(3, 0, 1, 2)}},
+		}},
+		{Meshes: []vtx.Mesh{
+			{StripGroups: []vtx.StripGroup{triangleListGroup(3, 0, 1, 2)}},
+			{StripGroups: []vtx.StripGroup{triangleListGroup(3, 2, 1, 0)}},
+		}},
+	}}
+
+	return &StudioModel{
+		Mdl: &mdl.Mdl{BodyParts: []mdl.BodyPartData{{
+			Header: mdl.BodyPart{NumModels: 1},
+			Models: []mdl.ModelData{{Header: modelHeader, Meshes: meshes}},
+		}}},
+		Vvd: vvdFile,
+		Vtx: &vtx.Vtx{NumLODs: 2, BodyParts: []vtx.BodyPart{{Models: []vtx.Model{vtxModel}}}},
+	}
+}
+
+func TestMeshVertices(t *testing.T) {
+	cases := []struct {
+		name      string
+		fixups    bool
+		mesh, lod int
+		// want holds the X of each vertex, which is its index in Vvd.Vertices
+		want      []float32
+		start     int
+		triangles [][3]uint32
+	}{
+		{"fixups mesh 0 lod 0", true, 0, 0, []float32{0, 1, 2, 3}, 0, [][3]uint32{{0, 1, 2}, {0, 2, 3}}},
+		{"fixups mesh 1 lod 0", true, 1, 0, []float32{4, 5, 6}, 4, [][3]uint32{{4, 5, 6}}},
+		{"fixups mesh 0 lod 1", true, 0, 1, []float32{0, 1, 2}, 0, [][3]uint32{{0, 1, 2}}},
+		{"fixups mesh 1 lod 1", true, 1, 1, []float32{4, 5, 6}, 3, [][3]uint32{{5, 4, 3}}},
+		{"shared mesh 0 lod 0", false, 0, 0, []float32{2, 3, 4, 5}, 2, [][3]uint32{{2, 3, 4}, {2, 4, 5}}},
+		{"shared mesh 1 lod 0", false, 1, 0, []float32{6, 7, 8}, 6, [][3]uint32{{6, 7, 8}}},
+		{"shared mesh 1 lod 1", false, 1, 1, []float32{6, 7, 8}, 6, [][3]uint32{{8, 7, 6}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := vertexTestModel(tc.fixups)
+
+			start, count, err := model.MeshVertexRange(0, 0, tc.mesh, tc.lod)
+			if err != nil {
+				t.Fatalf("MeshVertexRange failed: %v", err)
+			}
+			if start != tc.start || count != len(tc.want) {
+				t.Errorf("range = %d+%d, want %d+%d", start, count, tc.start, len(tc.want))
+			}
+
+			vertices, tangents, err := model.MeshVertices(0, 0, tc.mesh, tc.lod)
+			if err != nil {
+				t.Fatalf("MeshVertices failed: %v", err)
+			}
+			if len(vertices) != len(tc.want) || len(tangents) != len(tc.want) {
+				t.Fatalf("got %d vertices and %d tangents, want %d", len(vertices), len(tangents), len(tc.want))
+			}
+			for i, x := range tc.want {
+				if vertices[i].Position.X() != x || tangents[i].X() != x {
+					t.Errorf("vertex %d = %v with tangent %v, want x=%v", i, vertices[i].Position, tangents[i], x)
+				}
+			}
+
+			triangles, err := model.MeshTriangles(0, 0, tc.mesh, tc.lod)
+			if err != nil {
+				t.Fatalf("MeshTriangles failed: %v", err)
+			}
+			if len(triangles) != len(tc.triangles) {
+				t.Fatalf("triangles = %v, want %v", triangles, tc.triangles)
+			}
+			lodVertices, err := model.Vvd.VerticesForLOD(tc.lod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range triangles {
+				if triangles[i] != tc.triangles[i] {
+					t.Errorf("triangles = %v, want %v", triangles, tc.triangles)
+					break
+				}
+				// Triangle indices address the whole LOD array
+				for _, index := range triangles[i] {
+					if x := lodVertices[index].Position.X(); x < tc.want[0] || x > tc.want[len(tc.want)-1] {
+						t.Errorf("triangle %d index %d reaches vertex x=%v outside the mesh", i, index, x)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMeshVerticesErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(*StudioModel)
+		mesh   int
+		lod    int
+		// triangles is whether the error comes only from MeshTriangles
+		triangles bool
+		want      string
+	}{
+		{"lod out of range", nil, 0, 2, false, "lod 2 out of range"},
+		{"negative lod", nil, 0, -1, false, "lod -1 out of range"},
+		{"mesh out of range", nil, 2, 0, false, "mesh index 2 out of range"},
+		{"no vvd", func(model *StudioModel) { model.Vvd = nil }, 0, 0, false, "requires both an mdl and a vvd"},
+		{"mesh past lod vertices", func(model *StudioModel) {
+			model.Mdl.BodyParts[0].Models[0].Meshes[1].NumLODVertexes[0] = 10
+		}, 1, 0, false, "out of range (lod 0 has 7 vertices)"},
+		{"triangle past mesh", func(model *StudioModel) {
+			model.Vtx.BodyParts[0].Models[0].LODS[0].Meshes[1].StripGroups[0] = triangleListGroup(4, 0, 1, 3)
+		}, 1, 0, true, "references mesh vertex 3 (mesh has 3 vertices)"},
+		{"missing vtx mesh", func(model *StudioModel) {
+			model.Vtx.BodyParts[0].Models[0].LODS[1].Meshes = model.Vtx.BodyParts[0].Models[0].LODS[1].Meshes[:1]
+		}, 1, 1, true, "vtx has no mesh 1"},
+		{"no vtx", func(model *StudioModel) { model.Vtx = nil }, 0, 0, true, "requires a vtx"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := vertexTestModel(true)
+			if tc.mutate != nil {
+				tc.mutate(model)
+			}
+
+			_, _, err := model.MeshVertices(0, 0, tc.mesh, tc.lod)
+			if tc.triangles {
+				if err != nil && model.Vvd != nil {
+					t.Fatalf("MeshVertices failed: %v", err)
+				}
+				_, err = model.MeshTriangles(0, 0, tc.mesh, tc.lod)
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q does not mention %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package vpk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrCRCMismatch is returned when an entry's data does not match its CRC
+var ErrCRCMismatch = errors.New("vpk entry crc mismatch")
+
+// VPK is a vpk directory and its numbered archives. It implements fs.FS, fs.ReadFileFS,
+// fs.ReadDirFS and fs.StatFS; names are matched case-insensitively.
+type VPK struct {
+	*Directory
+	fsys fs.FS
+	// archivePrefix is the directory file path without its "_dir.vpk" suffix.
+	// Empty for single file vpks, which have no numbered archives
+	archivePrefix string
+	entries       map[string]*Entry
+	// dirs maps every directory to the sorted names of its children
+	dirs map[string][]string
+}
+
+// Open reads a vpk directory file from fsys. Numbered archives are opened from the same
+// location when entries are read, e.g. pak01_000.vpk for pak01_dir.vpk.
+func Open(fsys fs.FS, dirPath string) (*VPK, error) {
+	file, err := fsys.Open(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	directory, err := NewReader().Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dirPath, err)
+	}
+	return New(directory, fsys, dirPath), nil
+}
+
+// New wraps a parsed directory. dirPath locates the numbered archives in fsys.
+func New(directory *Directory, fsys fs.FS, dirPath string) *VPK {
+	vpk := &VPK{
+		Directory: directory,
+		fsys:      fsys,
+		entries:   make(map[string]*Entry, len(directory.Entries)),
+		dirs:      map[string][]string{".": {}},
+	}
+	if strings.HasSuffix(strings.ToLower(dirPath), "_dir.vpk") {
+		vpk.archivePrefix = dirPath[:len(dirPath)-len("_dir.vpk")]
+	}
+
+	children := map[string]map[string]bool{".": {}}
+	for i := range directory.Entries {
+		entry := &directory.Entries[i]
+		vpk.entries[entry.Path] = entry
+
+		// Register the file and every parent directory with its parent
+		child := entry.Path
+		for child != "." {
+			parent := path.Dir(child)
+			if children[parent] == nil {
+				children[parent] = make(map[string]bool)
+			}
+			children[parent][path.Base(child)] = true
+			child = parent
+		}
+	}
+	for dir, names := range children {
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+		vpk.dirs[dir] = sorted
+	}
+
+	return vpk
+}
+
+// Entry returns the entry for a path. Backslashes and a leading slash are accepted
+func (vpk *VPK) Entry(name string) (*Entry, bool) {
+	entry, ok := vpk.entries[normalisePath(name)]
+	return entry, ok
+}
+
+// ReadEntry reads an entry's full data and verifies its CRC
+func (vpk *VPK) ReadEntry(entry *Entry) ([]byte, error) {
+	// Length is untrusted, so the archive data is bounds checked before anything is
+	// allocated for it
+	var archiveData []byte
+	if entry.Length > 0 {
+		var err error
+		if archiveData, err = vpk.readArchive(entry); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+	}
+
+	data := make([]byte, 0, len(entry.Preload)+len(archiveData))
+	data = append(data, entry.Preload...)
+	data = append(data, archiveData...)
+
+	if crc := crc32.ChecksumIEEE(data); crc != entry.CRC {
+		return nil, fmt.Errorf("%w: %s has crc 0x%08x, expected 0x%08x", ErrCRCMismatch, entry.Path, crc, entry.CRC)
+	}
+	return data, nil
+}
+
+// readArchive reads the non-preload part of an entry
+func (vpk *VPK) readArchive(entry *Entry) ([]byte, error) {
+	start := int64(entry.Offset)
+	end := start + int64(entry.Length)
+
+	if entry.ArchiveIndex == EmbeddedArchiveIndex {
+		if end > int64(len(vpk.embedded)) {
+			return nil, fmt.Errorf("data [%d:%d] exceeds directory file data section (size %d)", start, end, len(vpk.embedded))
+		}
+		return vpk.embedded[start:end], nil
+	}
+
+	if vpk.archivePrefix == "" || vpk.fsys == nil {
+		return nil, fmt.Errorf("entry is stored in archive %d, but the vpk has no numbered archives", entry.ArchiveIndex)
+	}
+	archivePath := fmt.Sprintf("%s_%03d.vpk", vpk.archivePrefix, entry.ArchiveIndex)
+	file, err := vpk.fsys.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if end > info.Size() {
+		return nil, fmt.Errorf("data [%d:%d] exceeds %s (size %d)", start, end, archivePath, info.Size())
+	}
+
+	data := make([]byte, entry.Length)
+	switch archive := file.(type) {
+	case io.ReaderAt:
+		_, err = archive.ReadAt(data, start)
+	case io.Seeker:
+		if _, err = archive.Seek(start, io.SeekStart); err == nil {
+			_, err = io.ReadFull(file, data)
+		}
+	default:
+		if _, err = io.CopyN(io.Discard, file, start); err == nil {
+			_, err = io.ReadFull(file, data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read [%d:%d] from %s: %w", start, end, archivePath, err)
+	}
+
+	return data, nil
+}
+
+// Open implements fs.FS
+func (vpk *VPK) Open(name string) (fs.File, error) {
+	key, ok := fsKey(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if entry, ok := vpk.entries[key]; ok {
+		data, err := vpk.ReadEntry(entry)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &file{
+			Reader: bytes.NewReader(data),
+			info:   fileInfo{name: path.Base(key), size: entry.Size()},
+		}, nil
+	}
+
+	if names, ok := vpk.dirs[key]; ok {
+		return &dir{
+			vpk:   vpk,
+			path:  key,
+			names: names,
+		}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile implements fs.ReadFileFS
+func (vpk *VPK) ReadFile(name string) ([]byte, error) {
+	key, ok := fsKey(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := vpk.entries[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+	data, err := vpk.ReadEntry(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (vpk *VPK) ReadDir(name string) ([]fs.DirEntry, error) {
+	key, ok := fsKey(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	names, ok := vpk.dirs[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	d := &dir{vpk: vpk, path: key, names: names}
+	return d.ReadDir(-1)
+}
+
+// Stat implements fs.StatFS
+func (vpk *VPK) Stat(name string) (fs.FileInfo, error) {
+	key, ok := fsKey(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	info, ok := vpk.stat(key)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+// stat returns the info of a normalised path without reading its data
+func (vpk *VPK) stat(key string) (fileInfo, bool) {
+	if entry, ok := vpk.entries[key]; ok {
+		return fileInfo{name: path.Base(key), size: entry.Size()}, true
+	}
+	if _, ok := vpk.dirs[key]; ok {
+		return fileInfo{name: path.Base(key), dir: true}, true
+	}
+	return fileInfo{}, false
+}
+
+// fsKey validates an fs.FS name and returns its lookup key. Unlike Entry, fs.FS names must
+// use forward slashes.
+func fsKey(name string) (string, bool) {
+	if !fs.ValidPath(name) || strings.Contains(name, `\`) {
+		return "", false
+	}
+	return strings.ToLower(name), true
+}
+
+// normalisePath lowercases a path and converts it to a clean, slash separated, relative path
+func normalisePath(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, `\`, "/"))
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return "."
+	}
+	return name[1:]
+}
+
+// file is an open vpk entry
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+// Stat implements fs.File
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close implements fs.File
+func (f *file) Close() error {
+	return nil
+}
+
+// dir is an open vpk directory
+type dir struct {
+	vpk    *VPK
+	path   string
+	names  []string
+	offset int
+}
+
+// Stat implements fs.File
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: path.Base(d.path), dir: true}, nil
+}
+
+// Read implements fs.File
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// Close implements fs.File
+func (d *dir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := len(d.names) - d.offset
+	if n > 0 && remaining == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > remaining {
+		n = remaining
+	}
+
+	entries := make([]fs.DirEntry, 0, n)
+	for _, name := range d.names[d.offset : d.offset+n] {
+		info, _ := d.vpk.stat(path.Join(d.path, name))
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	d.offset += n
+
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+// Name implements fs.FileInfo
+func (info fileInfo) Name() string {
+	return info.name
+}
+
+// Size implements fs.FileInfo
+func (info fileInfo) Size() int64 {
+	return info.size
+}
+
+// Mode implements fs.FileInfo
+func (info fileInfo) Mode() fs.FileMode {
+	if info.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// ModTime implements fs.FileInfo. Vpks do not record modification times
+func (info fileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+// IsDir implements fs.FileInfo
+func (info fileInfo) IsDir() bool {
+	return info.dir
+}
+
+// Sys implements fs.FileInfo
+func (info fileInfo) Sys() any {
+	return nil
+}
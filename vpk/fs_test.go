@@ -0,0 +1,185 @@
+package vpk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// testEntry is a file to lay out in a test vpk
+type testEntry struct {
+	extension, dir, name string
+	preload              []byte
+	archive              uint16
+	offset, length       uint32
+	// crc overrides the checksum of the file's real data when non-zero
+	crc uint32
+	// data is the full file, used to compute the crc
+	data []byte
+}
+
+// buildDirectory writes a version 2 directory file. Entries must be grouped by extension
+// then directory, as they are in real vpks.
+func buildDirectory(entries []testEntry, embedded []byte) []byte {
+	tree := bytes.Buffer{}
+	writeString := func(s string) {
+		tree.WriteString(s)
+		tree.WriteByte(0)
+	}
+	for i, entry := range entries {
+		if i == 0 || entry.extension != entries[i-1].extension {
+			if i > 0 {
+				tree.WriteByte(0) // end of names
+				tree.WriteByte(0) // end of directories
+			}
+			writeString(entry.extension)
+			writeString(entry.dir)
+		} else if entry.dir != entries[i-1].dir {
+			tree.WriteByte(0)
+			writeString(entry.dir)
+		}
+		writeString(entry.name)
+
+		crc := entry.crc
+		if crc == 0 {
+			crc = crc32.ChecksumIEEE(entry.data)
+		}
+		_ = binary.Write(&tree, binary.LittleEndian, crc)
+		_ = binary.Write(&tree, binary.LittleEndian, uint16(len(entry.preload)))
+		_ = binary.Write(&tree, binary.LittleEndian, entry.archive)
+		_ = binary.Write(&tree, binary.LittleEndian, entry.offset)
+		_ = binary.Write(&tree, binary.LittleEndian, entry.length)
+		_ = binary.Write(&tree, binary.LittleEndian, uint16(entryTerminator))
+		tree.Write(entry.preload)
+	}
+	tree.Write([]byte{0, 0, 0})
+
+	out := bytes.Buffer{}
+	_ = binary.Write(&out, binary.LittleEndian, Header{
+		Signature:           Signature,
+		Version:             2,
+		TreeSize:            uint32(tree.Len()),
+		FileDataSectionSize: uint32(len(embedded)),
+	})
+	out.Write(tree.Bytes())
+	out.Write(embedded)
+	return out.Bytes()
+}
+
+// testVPK returns a vpk with a preloaded file, an embedded file and a file in archive 000
+func testVPK(t *testing.T, extra ...testEntry) *VPK {
+	t.Helper()
+
+	entries := []testEntry{
+		{extension: "mdl", dir: "models/props", name: "crate", archive: 0, offset: 4, length: 6, data: []byte("crate!")},
+		{extension: "txt", dir: " ", name: "readme", preload: []byte("hello "), archive: EmbeddedArchiveIndex, offset: 0, length: 5, data: []byte("hello world")},
+		{extension: "vmt", dir: "materials", name: "Wood", preload: []byte("\"LightmappedGeneric\""), archive: EmbeddedArchiveIndex, length: 0, data: []byte("\"LightmappedGeneric\"")},
+	}
+	entries = append(entries, extra...)
+
+	fsys := fstest.MapFS{
+		"pak01_dir.vpk": {Data: buildDirectory(entries, []byte("world"))},
+		"pak01_000.vpk": {Data: []byte("....crate!")},
+	}
+	vpk, err := Open(fsys, "pak01_dir.vpk")
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	return vpk
+}
+
+func TestReadFile(t *testing.T) {
+	vpk := testVPK(t)
+
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"models/props/crate.mdl", "crate!"},
+		{"readme.txt", "hello world"},
+		{"materials/wood.vmt", "\"LightmappedGeneric\""},
+		{"MATERIALS/Wood.VMT", "\"LightmappedGeneric\""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := fs.ReadFile(vpk, tc.name)
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if string(data) != tc.want {
+				t.Errorf("got %q, want %q", data, tc.want)
+			}
+		})
+	}
+
+	if _, ok := vpk.Entry(`\models\props\crate.mdl`); !ok {
+		t.Error("Entry did not accept a backslashed path")
+	}
+	if _, err := fs.ReadFile(vpk, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestFS(t *testing.T) {
+	if err := fstest.TestFS(testVPK(t), "models/props/crate.mdl", "readme.txt", "materials/wood.vmt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadEntryRejectsBadData(t *testing.T) {
+	cases := []struct {
+		name  string
+		entry testEntry
+		want  error
+	}{
+		{"crc mismatch", testEntry{extension: "bin", dir: "bad", name: "crc", archive: 0, offset: 4, length: 6, crc: 1}, ErrCRCMismatch},
+		{"archive too short", testEntry{extension: "bin", dir: "bad", name: "archive", archive: 0, offset: 4, length: 0xffffffff}, nil},
+		{"embedded too short", testEntry{extension: "bin", dir: "bad", name: "embedded", archive: EmbeddedArchiveIndex, offset: 2, length: 0xfffffff0}, nil},
+		{"missing archive", testEntry{extension: "bin", dir: "bad", name: "missing", archive: 7, length: 1}, fs.ErrNotExist},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpk := testVPK(t, tc.entry)
+			entry, ok := vpk.Entry("bad/" + tc.entry.name + ".bin")
+			if !ok {
+				t.Fatal("entry not found")
+			}
+			_, err := vpk.ReadEntry(entry)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadRejectsCorruptDirectory(t *testing.T) {
+	valid := buildDirectory([]testEntry{{extension: "txt", dir: " ", name: "a", archive: EmbeddedArchiveIndex}}, nil)
+
+	cases := []struct {
+		name   string
+		mutate func([]byte) []byte
+	}{
+		{"too small", func(b []byte) []byte { return b[:8] }},
+		{"bad signature", func(b []byte) []byte { b[0] = 0; return b }},
+		{"bad version", func(b []byte) []byte { b[4] = 3; return b }},
+		{"tree past end", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[8:], 1<<20); return b }},
+		{"truncated tree", func(b []byte) []byte { return b[:len(b)-8] }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := tc.mutate(append([]byte(nil), valid...))
+			if _, err := ReadFromStream(bytes.NewReader(buf)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package vpk
+
+import "io"
+
+// ReadFromStream parses a vpk directory file from a io.Reader stream.
+// Entries stored in numbered archives cannot be read without them, see Open.
+func ReadFromStream(stream io.Reader) (*Directory, error) {
+	reader := NewReader()
+	return reader.Read(stream)
+}
@@ -0,0 +1,181 @@
+package vpk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// Reader
+type Reader struct {
+}
+
+// Read parses a stream of a vpk directory file
+func (reader *Reader) Read(stream io.Reader) (*Directory, error) {
+	byteBuf := bytes.Buffer{}
+	if _, err := byteBuf.ReadFrom(stream); err != nil {
+		return nil, err
+	}
+	buf := byteBuf.Bytes()
+
+	header, headerSize, err := reader.readHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	treeEnd := headerSize + int(header.TreeSize)
+	if treeEnd > len(buf) {
+		return nil, fmt.Errorf("directory tree of %d bytes exceeds file (size %d)", header.TreeSize, len(buf))
+	}
+	entries, err := reader.readTree(buf[headerSize:treeEnd])
+	if err != nil {
+		return nil, err
+	}
+
+	// Version 1 files have no section sizes, so everything after the tree is data
+	embedded := buf[treeEnd:]
+	if header.Version == 2 {
+		if int(header.FileDataSectionSize) > len(embedded) {
+			return nil, fmt.Errorf("file data section of %d bytes exceeds file", header.FileDataSectionSize)
+		}
+		embedded = embedded[:header.FileDataSectionSize]
+	}
+
+	return &Directory{
+		Header:   header,
+		Entries:  entries,
+		embedded: embedded,
+	}, nil
+}
+
+// readHeader reads a version 1 or 2 header, also returning its size
+func (reader *Reader) readHeader(buf []byte) (Header, int, error) {
+	v1Size := int(unsafe.Sizeof(headerV1{}))
+	v2Size := int(unsafe.Sizeof(Header{}))
+	if len(buf) < v1Size {
+		return Header{}, 0, errors.New("file too small to be a vpk")
+	}
+
+	header := Header{
+		Signature: binary.LittleEndian.Uint32(buf[0:4]),
+		Version:   binary.LittleEndian.Uint32(buf[4:8]),
+		TreeSize:  binary.LittleEndian.Uint32(buf[8:12]),
+	}
+	if header.Signature != Signature {
+		return Header{}, 0, fmt.Errorf("invalid vpk signature 0x%08x (not a _dir.vpk?)", header.Signature)
+	}
+
+	switch header.Version {
+	case 1:
+		return header, v1Size, nil
+	case 2:
+		if len(buf) < v2Size {
+			return Header{}, 0, errors.New("file too small for a version 2 vpk header")
+		}
+		err := binary.Read(bytes.NewBuffer(buf[:v2Size]), binary.LittleEndian, &header)
+		return header, v2Size, err
+	default:
+		return Header{}, 0, fmt.Errorf("unsupported vpk version %d", header.Version)
+	}
+}
+
+// readTree reads the extension / path / filename tree
+func (reader *Reader) readTree(tree []byte) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	offset := 0
+
+	next := func() (string, error) {
+		end := bytes.IndexByte(tree[offset:], 0)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string at tree offset %d", offset)
+		}
+		s := string(tree[offset : offset+end])
+		offset += end + 1
+		return s, nil
+	}
+
+	for {
+		extension, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if extension == "" {
+			break
+		}
+		for {
+			dir, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if dir == "" {
+				break
+			}
+			for {
+				name, err := next()
+				if err != nil {
+					return nil, err
+				}
+				if name == "" {
+					break
+				}
+
+				entry, err := reader.readEntry(tree, &offset)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", joinPath(dir, name, extension), err)
+				}
+				entry.Path = joinPath(dir, name, extension)
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// readEntry reads a directory entry and its preload data
+func (reader *Reader) readEntry(tree []byte, offset *int) (Entry, error) {
+	const entrySize = 18
+	if *offset+entrySize > len(tree) {
+		return Entry{}, errors.New("directory entry exceeds tree")
+	}
+	data := tree[*offset : *offset+entrySize]
+	entry := Entry{
+		CRC:          binary.LittleEndian.Uint32(data[0:4]),
+		ArchiveIndex: binary.LittleEndian.Uint16(data[6:8]),
+		Offset:       binary.LittleEndian.Uint32(data[8:12]),
+		Length:       binary.LittleEndian.Uint32(data[12:16]),
+	}
+	preloadSize := int(binary.LittleEndian.Uint16(data[4:6]))
+	if terminator := binary.LittleEndian.Uint16(data[16:18]); terminator != entryTerminator {
+		return Entry{}, fmt.Errorf("invalid entry terminator 0x%04x", terminator)
+	}
+	*offset += entrySize
+
+	if *offset+preloadSize > len(tree) {
+		return Entry{}, errors.New("preload data exceeds tree")
+	}
+	entry.Preload = tree[*offset : *offset+preloadSize]
+	*offset += preloadSize
+
+	return entry, nil
+}
+
+// joinPath builds a full path from its tree components. A single space marks an empty component.
+func joinPath(dir string, name string, extension string) string {
+	path := name
+	if extension != " " {
+		path += "." + extension
+	}
+	if dir != " " {
+		path = dir + "/" + path
+	}
+	return normalisePath(path)
+}
+
+// NewReader returns a new reader
+func NewReader() *Reader {
+	return new(Reader)
+}
@@ -0,0 +1,80 @@
+package vpk
+
+// Signature identifies a vpk directory file
+const Signature = 0x55aa1234
+
+// EmbeddedArchiveIndex marks entries stored in the directory file itself
+const EmbeddedArchiveIndex = 0x7fff
+
+// entryTerminator ends every directory entry
+const entryTerminator = 0xffff
+
+// Header is the header of a vpk directory file
+type Header struct {
+	// Signature
+	// Always Signature
+	Signature uint32
+	// Version
+	// 1 or 2
+	Version uint32
+	// TreeSize
+	// Size of the directory tree in bytes
+	TreeSize uint32
+	// FileDataSectionSize
+	// Size of the data embedded after the tree. Version 2 only
+	FileDataSectionSize uint32
+	// ArchiveMD5SectionSize
+	// Version 2 only
+	ArchiveMD5SectionSize uint32
+	// OtherMD5SectionSize
+	// Version 2 only
+	OtherMD5SectionSize uint32
+	// SignatureSectionSize
+	// Version 2 only
+	SignatureSectionSize uint32
+}
+
+// headerV1
+type headerV1 struct {
+	Signature uint32
+	Version   uint32
+	TreeSize  uint32
+}
+
+// Entry is a file in a vpk
+type Entry struct {
+	// Path
+	// Full lowercased path of the file, using forward slashes
+	Path string
+	// CRC
+	// CRC32 (IEEE) of the complete file
+	CRC uint32
+	// Preload
+	// Bytes stored in the directory tree, which come before the archive data
+	Preload []byte
+	// ArchiveIndex
+	// Numbered archive holding the rest of the file, or EmbeddedArchiveIndex
+	ArchiveIndex uint16
+	// Offset
+	// Offset of the data within its archive
+	Offset uint32
+	// Length
+	// Length of the data within its archive, excluding Preload
+	Length uint32
+}
+
+// Size returns the full size of the file
+func (entry *Entry) Size() int64 {
+	return int64(len(entry.Preload)) + int64(entry.Length)
+}
+
+// Directory is a parsed vpk directory file
+type Directory struct {
+	// Header
+	Header Header
+	// Entries
+	// Every file, in tree order
+	Entries []Entry
+	// embedded holds the data section that follows the tree in the directory file
+	embedded []byte
+}
@@ -0,0 +1,109 @@
+package vtx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Variant is a vtx flavour, identified by its file suffix
+type Variant string
+
+const (
+	// VariantDX90 is the DirectX 9 vtx
+	VariantDX90 Variant = ".dx90.vtx"
+	// VariantDX80 is the DirectX 8 vtx
+	VariantDX80 Variant = ".dx80.vtx"
+	// VariantSW is the software rendering vtx
+	VariantSW Variant = ".sw.vtx"
+	// VariantGeneric is the vtx used by branches that ship a single file
+	VariantGeneric Variant = ".vtx"
+	// VariantXbox is the original Xbox vtx
+	VariantXbox Variant = ".xbox.vtx"
+	// VariantXbox360 is the byte swapped Xbox 360 and PS3 vtx
+	VariantXbox360 Variant = ".360.vtx"
+)
+
+// DefaultVariantPreference is the order variants are tried in when none is configured
+var DefaultVariantPreference = []Variant{VariantDX90, VariantDX80, VariantSW, VariantGeneric, VariantXbox}
+
+var (
+	// ErrNoVariant is returned when none of the preferred variants exist
+	ErrNoVariant = fmt.Errorf("no vtx variant found: %w", fs.ErrNotExist)
+	// ErrChecksumMismatch is returned when a vtx does not belong to the mdl it was loaded for.
+	// studiomodel.ErrChecksumMismatch is the same error, so one check covers every file
+	ErrChecksumMismatch = errors.New("checksum does not match mdl")
+)
+
+// Loader finds and reads the most preferred vtx variant available for a model
+type Loader struct {
+	// Preference lists variants in the order they are tried
+	Preference []Variant
+}
+
+// Load reads the vtx for a model. basePath is the model path with or without its .mdl extension.
+// Variants that fail to open or parse, or whose checksum does not match mdlChecksum, are
+// skipped; if no variant loads, the error from the first such file is returned.
+// mdlVersion is used to pick the strip group layout.
+func (loader *Loader) Load(fsys fs.FS, basePath string, mdlVersion int32, mdlChecksum int32) (*Vtx, error) {
+	basePath = strings.TrimSuffix(basePath, ".mdl")
+
+	preference := loader.Preference
+	if len(preference) == 0 {
+		preference = DefaultVariantPreference
+	}
+
+	var firstErr error
+	for _, variant := range preference {
+		filePath := basePath + string(variant)
+		file, err := fsys.Open(filePath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to open %s: %w", filePath, err)
+			}
+			continue
+		}
+
+		reader := NewReader()
+		reader.MdlVersion = mdlVersion
+		out, err := reader.Read(file)
+		_ = file.Close()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to read %s: %w", filePath, err)
+			}
+			continue
+		}
+
+		if out.CheckSum != mdlChecksum {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%w: %s has checksum %d, mdl has %d", ErrChecksumMismatch, filePath, out.CheckSum, mdlChecksum)
+			}
+			continue
+		}
+
+		out.Variant = variant
+		return out, nil
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, fmt.Errorf("%w for %s", ErrNoVariant, basePath)
+}
+
+// NewLoader returns a new Loader. DefaultVariantPreference is used if no preference is passed.
+func NewLoader(preference ...Variant) *Loader {
+	return &Loader{
+		Preference: preference,
+	}
+}
+
+// LoadFromFS reads the best available vtx for a model using DefaultVariantPreference
+func LoadFromFS(fsys fs.FS, basePath string, mdlVersion int32, mdlChecksum int32) (*Vtx, error) {
+	return NewLoader().Load(fsys, basePath, mdlVersion, mdlChecksum)
+}
@@ -0,0 +1,61 @@
+package vtx
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoaderFallsBack(t *testing.T) {
+	valid := buildStripFixture(t, binary.LittleEndian, StripGroupLayoutStandard)
+	corrupt := []byte{7, 0, 0, 0, 1, 2, 3}
+
+	cases := []struct {
+		name     string
+		files    fstest.MapFS
+		checksum int32
+		want     Variant
+		wantErr  error
+		errText  string
+	}{
+		{"preferred", fstest.MapFS{"m.dx90.vtx": {Data: valid}, "m.dx80.vtx": {Data: valid}}, 1234, VariantDX90, nil, ""},
+		{"skips missing", fstest.MapFS{"m.sw.vtx": {Data: valid}}, 1234, VariantSW, nil, ""},
+		{"skips corrupt", fstest.MapFS{"m.dx90.vtx": {Data: corrupt}, "m.dx80.vtx": {Data: valid}}, 1234, VariantDX80, nil, ""},
+		{"skips mismatch", fstest.MapFS{"m.dx90.vtx": {Data: valid}, "m.vtx": {Data: valid}}, 99, "", ErrChecksumMismatch, ""},
+		{"only corrupt", fstest.MapFS{"m.dx90.vtx": {Data: corrupt}}, 1234, "", nil, "failed to read m.dx90.vtx"},
+		{"first error wins", fstest.MapFS{"m.dx90.vtx": {Data: corrupt}, "m.dx80.vtx": {Data: valid}}, 99, "", nil, "failed to read m.dx90.vtx"},
+		{"none", fstest.MapFS{}, 1234, "", ErrNoVariant, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := NewLoader().Load(tc.files, "m.mdl", 48, tc.checksum)
+			if tc.want != "" {
+				if err != nil {
+					t.Fatalf("load failed: %v", err)
+				}
+				if out.Variant != tc.want {
+					t.Errorf("variant = %q, want %q", out.Variant, tc.want)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected an error, loaded %q", out.Variant)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("error = %v, want %v", err, tc.wantErr)
+			}
+			if tc.errText != "" && !strings.Contains(err.Error(), tc.errText) {
+				t.Errorf("error %q does not mention %q", err, tc.errText)
+			}
+		})
+	}
+
+	if !errors.Is(ErrNoVariant, fs.ErrNotExist) {
+		t.Error("ErrNoVariant should wrap fs.ErrNotExist")
+	}
+}
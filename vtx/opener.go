@@ -2,7 +2,16 @@ package vtx
 
 import "io"
 
+// ReadFromStream parses a vtx from an io.Reader stream
 func ReadFromStream(stream io.Reader) (*Vtx, error) {
 	reader := NewReader()
 	return reader.Read(stream)
 }
+
+// ReadFromStreamForMdlVersion parses a vtx from an io.Reader stream, using the version
+// of the accompanying mdl to pick the strip group layout
+func ReadFromStreamForMdlVersion(stream io.Reader, mdlVersion int32) (*Vtx, error) {
+	reader := NewReader()
+	reader.MdlVersion = mdlVersion
+	return reader.Read(stream)
+}
@@ -17,7 +17,14 @@ const (
 
 // Reader
 type Reader struct {
-	buf []byte
+	// MdlVersion is the version of the mdl this vtx belongs to, if known.
+	// Used to decide which strip group layouts are worth trying
+	MdlVersion int32
+	// StripGroupLayout forces a strip group header layout. Detected by default
+	StripGroupLayout StripGroupLayout
+
+	buf       []byte
+	byteOrder binary.ByteOrder
 }
 
 // Read parses a stream to a Vtx struct
@@ -34,6 +41,12 @@ func (reader *Reader) Read(stream io.Reader) (*Vtx, error) {
 		return nil, fmt.Errorf("vtx file too small: %d bytes, expected at least %d", len(reader.buf), unsafe.Sizeof(header{}))
 	}
 
+	// VTX has no magic number, so the version is used to detect byte swapped console files
+	reader.byteOrder, err = internal.DetectByteOrder(reader.buf, VTXVersion)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported VTX version: %w", err)
+	}
+
 	// Read header
 	header, err := reader.readHeader()
 	if err != nil {
@@ -55,7 +68,55 @@ func (reader *Reader) Read(stream io.Reader) (*Vtx, error) {
 		return nil, fmt.Errorf("VTX body part offset %d out of bounds (file size %d)", header.BodyPartOffset, len(reader.buf))
 	}
 
-	out := Vtx{}
+	// Material replacements are independent of the rest of the hierarchy
+	materialReplacements, err := reader.readMaterialReplacements(&header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read material replacements: %w", err)
+	}
+
+	// The strip group layout can't be read from the file, so try each plausible
+	// layout until one produces consistent data
+	layouts := reader.candidateLayouts()
+	var firstErr error
+	for _, layout := range layouts {
+		out, err := reader.readBodyPartTree(&header, layout)
+		if err == nil && len(layouts) > 1 {
+			err = validateStripGroups(out)
+		}
+		if err == nil {
+			out.MaterialReplacements = materialReplacements
+			return out, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+// candidateLayouts returns the strip group layouts to try, most likely first
+func (reader *Reader) candidateLayouts() []StripGroupLayout {
+	switch {
+	case reader.StripGroupLayout != StripGroupLayoutAuto:
+		return []StripGroupLayout{reader.StripGroupLayout}
+	case reader.MdlVersion >= extendedStripGroupMinMdlVersion:
+		return []StripGroupLayout{StripGroupLayoutExtended, StripGroupLayoutStandard}
+	case reader.MdlVersion > 0:
+		return []StripGroupLayout{StripGroupLayoutStandard}
+	default:
+		return []StripGroupLayout{StripGroupLayoutStandard, StripGroupLayoutExtended}
+	}
+}
+
+// readBodyPartTree parses the body part → model → lod → mesh → strip group hierarchy
+func (reader *Reader) readBodyPartTree(header *header, layout StripGroupLayout) (*Vtx, error) {
+	out := Vtx{
+		NumLODs:          header.NumLODs,
+		CheckSum:         header.CheckSum,
+		ByteOrder:        reader.byteOrder,
+		StripGroupLayout: layout,
+	}
 
 	// Parse body parts
 	bodyPartHeaderSize := internal.SizeOf(&bodyPartHeader{})
@@ -100,7 +161,9 @@ func (reader *Reader) Read(stream io.Reader) (*Vtx, error) {
 
 			// Iterate through LODs
 			for k, modelLODHeader := range modelLODHeaders {
-				modelLODOut := ModelLOD{}
+				modelLODOut := ModelLOD{
+					SwitchPoint: modelLODHeader.SwitchPoint,
+				}
 				modelLODPos := modelLODStart + (int32(k) * modelLODHeaderSize)
 				meshStart := modelLODPos + modelLODHeader.MeshOffset
 
@@ -115,13 +178,15 @@ func (reader *Reader) Read(stream io.Reader) (*Vtx, error) {
 
 				// Iterate through meshes
 				for l, meshHeader := range meshHeaders {
-					meshOut := Mesh{}
+					meshOut := Mesh{
+						Flags: meshHeader.Flags,
+					}
 					meshPos := meshStart + (int32(l) * meshHeaderSize)
 					stripGroupStart := meshPos + meshHeader.StripGroupHeaderOffset
 
 					// Parse strip groups
-					stripGroupHeaderSize := int32(25) // VTX ignores trailing byte 4-byte alignment
-					stripGroupHeaders, err := reader.readStripGroups(stripGroupStart, meshHeader.NumStripGroups)
+					stripGroupHeaderSize := layout.headerSize()
+					stripGroupHeaders, topologies, err := reader.readStripGroups(stripGroupStart, meshHeader.NumStripGroups, layout)
 					if err != nil {
 						return nil, fmt.Errorf("failed to read strip groups for mesh %d, LOD %d, model %d, body part %d: %w", l, k, j, i, err)
 					}
@@ -130,7 +195,9 @@ func (reader *Reader) Read(stream io.Reader) (*Vtx, error) {
 
 					// Iterate through strip groups
 					for m, stripGroupHeader := range stripGroupHeaders {
-						stripGroupOut := StripGroup{}
+						stripGroupOut := StripGroup{
+							Flags: stripGroupHeader.Flags,
+						}
 						stripGroupPos := stripGroupStart + (int32(m) * stripGroupHeaderSize)
 
 						// Read vertices, indices, and strips for this strip group
@@ -144,11 +211,18 @@ func (reader *Reader) Read(stream io.Reader) (*Vtx, error) {
 							return nil, fmt.Errorf("failed to read indices for strip group %d: %w", m, err)
 						}
 
-						stripGroupOut.Strips, err = reader.readStrips(stripGroupPos+stripGroupHeader.StripOffset, stripGroupHeader.NumStrips)
+						stripGroupOut.Strips, err = reader.readStrips(stripGroupPos+stripGroupHeader.StripOffset, stripGroupHeader.NumStrips, layout)
 						if err != nil {
 							return nil, fmt.Errorf("failed to read strips for strip group %d: %w", m, err)
 						}
 
+						if topologies != nil && topologies[m].NumTopologyIndices > 0 {
+							stripGroupOut.Topology, err = reader.readIndices(stripGroupPos+topologies[m].TopologyOffset, topologies[m].NumTopologyIndices)
+							if err != nil {
+								return nil, fmt.Errorf("failed to read topology for strip group %d: %w", m, err)
+							}
+						}
+
 						meshOut.StripGroups[m] = stripGroupOut
 					}
 
@@ -172,18 +246,21 @@ func (reader *Reader) readHeader() (header, error) {
 	header := header{}
 	headerSize := unsafe.Sizeof(header)
 
-	err := binary.Read(bytes.NewBuffer(reader.buf[:headerSize]), binary.LittleEndian, &header)
+	err := binary.Read(bytes.NewBuffer(reader.buf[:headerSize]), reader.byteOrder, &header)
 
 	return header, err
 }
 
 // readBodyParts
 func (reader *Reader) readBodyParts(offset int32, num int32) ([]bodyPartHeader, error) {
+	if num == 0 {
+		return make([]bodyPartHeader, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]bodyPartHeader, 0), errors.New("body part data out of bounds")
 	}
 	ret := make([]bodyPartHeader, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
+	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
 	if err != nil {
 		return nil, err
 	}
@@ -192,11 +269,14 @@ func (reader *Reader) readBodyParts(offset int32, num int32) ([]bodyPartHeader,
 
 // readModels
 func (reader *Reader) readModels(offset int32, num int32) ([]modelHeader, error) {
+	if num == 0 {
+		return make([]modelHeader, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]modelHeader, 0), errors.New("model data out of bounds")
 	}
 	ret := make([]modelHeader, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
+	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
 	if err != nil {
 		return nil, err
 	}
@@ -205,11 +285,14 @@ func (reader *Reader) readModels(offset int32, num int32) ([]modelHeader, error)
 
 // readModelLODs
 func (reader *Reader) readModelLODs(offset int32, num int32) ([]modelLODHeader, error) {
+	if num == 0 {
+		return make([]modelLODHeader, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]modelLODHeader, 0), errors.New("model lod data out of bounds")
 	}
 	ret := make([]modelLODHeader, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
+	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
 	if err != nil {
 		return nil, err
 	}
@@ -218,11 +301,14 @@ func (reader *Reader) readModelLODs(offset int32, num int32) ([]modelLODHeader,
 
 // readMeshes
 func (reader *Reader) readMeshes(offset int32, num int32) ([]meshHeader, error) {
+	if num == 0 {
+		return make([]meshHeader, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]meshHeader, 0), errors.New("mesh data out of bounds")
 	}
 	ret := make([]meshHeader, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
+	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
 	if err != nil {
 		return nil, err
 	}
@@ -230,25 +316,145 @@ func (reader *Reader) readMeshes(offset int32, num int32) ([]meshHeader, error)
 }
 
 // readStripGroups
-func (reader *Reader) readStripGroups(offset int32, num int32) ([]stripGroupHeader, error) {
+// Topology is only returned for the extended layout
+func (reader *Reader) readStripGroups(offset int32, num int32, layout StripGroupLayout) ([]stripGroupHeader, []stripGroupTopology, error) {
+	if num == 0 {
+		return make([]stripGroupHeader, 0), nil, nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
-		return make([]stripGroupHeader, 0), errors.New("strip group data out of bounds")
+		return make([]stripGroupHeader, 0), nil, errors.New("strip group data out of bounds")
 	}
+	if layout != StripGroupLayoutExtended {
+		ret := make([]stripGroupHeader, num)
+		err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ret, nil, nil
+	}
+
 	ret := make([]stripGroupHeader, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
-	if err != nil {
+	topologies := make([]stripGroupTopology, num)
+	standardSize := StripGroupLayoutStandard.headerSize()
+	for i := int32(0); i < num; i++ {
+		pos := offset + i*layout.headerSize()
+		if int(pos) >= len(reader.buf) {
+			return nil, nil, errors.New("strip group data out of bounds")
+		}
+		if err := binary.Read(bytes.NewBuffer(reader.buf[pos:]), reader.byteOrder, &ret[i]); err != nil {
+			return nil, nil, err
+		}
+		if err := binary.Read(bytes.NewBuffer(reader.buf[pos+standardSize:]), reader.byteOrder, &topologies[i]); err != nil {
+			return nil, nil, err
+		}
+		if topologies[i].NumTopologyIndices < 0 {
+			return nil, nil, fmt.Errorf("strip group %d has negative topology count %d", i, topologies[i].NumTopologyIndices)
+		}
+	}
+	return ret, topologies, nil
+}
+
+// readMaterialReplacements reads the per LOD material replacement lists ($lod replacematerial)
+func (reader *Reader) readMaterialReplacements(header *header) ([][]MaterialReplacement, error) {
+	if header.MaterialReplacementListOffset <= 0 || header.NumLODs == 0 {
+		return nil, nil
+	}
+
+	listHeaderSize := internal.SizeOf(&materialReplacementListHeader{})
+	listHeaders := make([]materialReplacementListHeader, header.NumLODs)
+	if err := reader.readStructs(header.MaterialReplacementListOffset, &listHeaders); err != nil {
 		return nil, err
 	}
-	return ret, nil
+
+	replacementSize := int32(binary.Size(materialReplacementHeader{}))
+	out := make([][]MaterialReplacement, len(listHeaders))
+	for lod, listHeader := range listHeaders {
+		if listHeader.NumReplacements < 0 {
+			return nil, fmt.Errorf("lod %d has negative replacement count %d", lod, listHeader.NumReplacements)
+		}
+		listPos := header.MaterialReplacementListOffset + int32(lod)*listHeaderSize
+		replacementStart := listPos + listHeader.ReplacementOffset
+
+		replacements := make([]materialReplacementHeader, listHeader.NumReplacements)
+		if err := reader.readStructs(replacementStart, &replacements); err != nil {
+			return nil, fmt.Errorf("failed to read replacements for lod %d: %w", lod, err)
+		}
+
+		out[lod] = make([]MaterialReplacement, len(replacements))
+		for i, replacement := range replacements {
+			namePos := replacementStart + int32(i)*replacementSize + replacement.ReplacementMaterialNameOffset
+			name, err := reader.readCString(namePos)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read replacement %d name for lod %d: %w", i, lod, err)
+			}
+			out[lod][i] = MaterialReplacement{
+				MaterialID: replacement.MaterialID,
+				Name:       name,
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// readStructs decodes data at offset into out, checking bounds first
+func (reader *Reader) readStructs(offset int32, out interface{}) error {
+	size := binary.Size(out)
+	if offset < 0 || size < 0 || int(offset)+size > len(reader.buf) {
+		return fmt.Errorf("data out of bounds: offset=%d size=%d bufferSize=%d", offset, size, len(reader.buf))
+	}
+	return binary.Read(bytes.NewBuffer(reader.buf[offset:int(offset)+size]), reader.byteOrder, out)
+}
+
+// readCString reads a null terminated string
+func (reader *Reader) readCString(offset int32) (string, error) {
+	if offset < 0 || int(offset) >= len(reader.buf) {
+		return "", fmt.Errorf("string offset %d out of bounds (buffer size %d)", offset, len(reader.buf))
+	}
+	end := bytes.IndexByte(reader.buf[offset:], 0)
+	if end < 0 {
+		return string(reader.buf[offset:]), nil
+	}
+	return string(reader.buf[offset : int(offset)+end]), nil
+}
+
+// validateStripGroups checks that strips and indices of every strip group stay within
+// their strip group. Used to reject a wrongly guessed strip group layout.
+func validateStripGroups(out *Vtx) error {
+	for _, bodyPart := range out.BodyParts {
+		for _, model := range bodyPart.Models {
+			for _, lod := range model.LODS {
+				for _, mesh := range lod.Meshes {
+					for i, stripGroup := range mesh.StripGroups {
+						for _, index := range stripGroup.Indices {
+							if int(index) >= len(stripGroup.Vertexes) {
+								return fmt.Errorf("strip group %d index %d out of range (have %d vertices)", i, index, len(stripGroup.Vertexes))
+							}
+						}
+						for j, strip := range stripGroup.Strips {
+							if strip.IndexOffset < 0 || strip.NumIndices < 0 || int(strip.IndexOffset+strip.NumIndices) > len(stripGroup.Indices) ||
+								strip.VertOffset < 0 || strip.NumVerts < 0 || int(strip.VertOffset+strip.NumVerts) > len(stripGroup.Vertexes) {
+								return fmt.Errorf("strip %d of strip group %d out of range", j, i)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // readIndices
 func (reader *Reader) readIndices(offset int32, num int32) ([]uint16, error) {
+	if num == 0 {
+		return make([]uint16, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]uint16, 0), errors.New("indices data out of bounds")
 	}
 	ret := make([]uint16, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
+	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
 	if err != nil {
 		return nil, err
 	}
@@ -257,11 +463,14 @@ func (reader *Reader) readIndices(offset int32, num int32) ([]uint16, error) {
 
 // readVertices
 func (reader *Reader) readVertices(offset int32, num int32) ([]Vertex, error) {
+	if num == 0 {
+		return make([]Vertex, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]Vertex, 0), errors.New("vertex data out of bounds")
 	}
 	ret := make([]Vertex, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
+	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), reader.byteOrder, &ret)
 	if err != nil {
 		return nil, err
 	}
@@ -269,14 +478,57 @@ func (reader *Reader) readVertices(offset int32, num int32) ([]Vertex, error) {
 }
 
 // readStrips
-func (reader *Reader) readStrips(offset int32, num int32) ([]Strip, error) {
+// Strip headers share the strip group layout, so extended files carry topology here too
+func (reader *Reader) readStrips(offset int32, num int32, layout StripGroupLayout) ([]Strip, error) {
+	if num == 0 {
+		return make([]Strip, 0), nil
+	}
 	if !isPropertyValid(offset, num, len(reader.buf)) {
 		return make([]Strip, 0), errors.New("strip data out of bounds")
 	}
+
+	stripSize := layout.stripHeaderSize()
+	standardSize := StripGroupLayoutStandard.stripHeaderSize()
 	ret := make([]Strip, num)
-	err := binary.Read(bytes.NewBuffer(reader.buf[offset:]), binary.LittleEndian, &ret)
-	if err != nil {
-		return nil, err
+	for i := int32(0); i < num; i++ {
+		// Bone state change offsets are relative to the start of their own strip header
+		stripPos := offset + i*stripSize
+		header := stripHeader{}
+		if err := reader.readStructs(stripPos, &header); err != nil {
+			return nil, fmt.Errorf("failed to read strip %d: %w", i, err)
+		}
+		ret[i] = Strip{
+			NumIndices:            header.NumIndices,
+			IndexOffset:           header.IndexOffset,
+			NumVerts:              header.NumVerts,
+			VertOffset:            header.VertOffset,
+			NumBones:              header.NumBones,
+			Flags:                 header.Flags,
+			NumBoneStateChanges:   header.NumBoneStateChanges,
+			BoneStateChangeOffset: header.BoneStateChangeOffset,
+		}
+
+		if layout == StripGroupLayoutExtended {
+			topology := stripGroupTopology{}
+			if err := reader.readStructs(stripPos+standardSize, &topology); err != nil {
+				return nil, fmt.Errorf("failed to read topology for strip %d: %w", i, err)
+			}
+			if topology.NumTopologyIndices < 0 {
+				return nil, fmt.Errorf("strip %d has negative topology count %d", i, topology.NumTopologyIndices)
+			}
+			ret[i].NumTopologyIndices = topology.NumTopologyIndices
+			ret[i].TopologyOffset = topology.TopologyOffset
+		}
+
+		if header.NumBoneStateChanges < 0 || int(header.NumBoneStateChanges) > len(reader.buf)/binary.Size(BoneStateChange{}) {
+			return nil, fmt.Errorf("strip %d has invalid bone state change count %d", i, header.NumBoneStateChanges)
+		}
+		if header.NumBoneStateChanges > 0 {
+			ret[i].BoneStateChanges = make([]BoneStateChange, header.NumBoneStateChanges)
+			if err := reader.readStructs(stripPos+header.BoneStateChangeOffset, &ret[i].BoneStateChanges); err != nil {
+				return nil, fmt.Errorf("failed to read bone state changes for strip %d: %w", i, err)
+			}
+		}
 	}
 	return ret, nil
 }
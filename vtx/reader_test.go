@@ -0,0 +1,164 @@
+package vtx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildStripFixture builds a vtx with one mesh holding a single strip group of two strips.
+// Each strip rebinds one hardware bone, so the bone state change offsets only resolve
+// when strips are read at the size of the given layout.
+func buildStripFixture(t *testing.T, order binary.ByteOrder, layout StripGroupLayout) []byte {
+	t.Helper()
+
+	const (
+		headerSize   = 36
+		bodyPartSize = 8
+		modelSize    = 8
+		lodSize      = 12
+		meshSize     = 9
+		vertexSize   = 9
+		numVerts     = 4
+		numIndices   = 6
+		numStrips    = 2
+	)
+	stripGroupSize := layout.headerSize()
+	stripSize := layout.stripHeaderSize()
+
+	bodyPartPos := int32(headerSize)
+	modelPos := bodyPartPos + bodyPartSize
+	lodPos := modelPos + modelSize
+	meshPos := lodPos + lodSize
+	stripGroupPos := meshPos + meshSize
+	stripPos := stripGroupPos + stripGroupSize
+	vertexPos := stripPos + numStrips*stripSize
+	indexPos := vertexPos + numVerts*vertexSize
+	boneStatePos := indexPos + numIndices*2
+
+	buf := &bytes.Buffer{}
+	write := func(data interface{}) {
+		if err := binary.Write(buf, order, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(header{Version: VTXVersion, CheckSum: 1234, NumLODs: 1, NumBodyParts: 1, BodyPartOffset: bodyPartPos})
+	write(bodyPartHeader{NumModels: 1, ModelOffset: modelPos - bodyPartPos})
+	write(modelHeader{NumLODs: 1, LODOffset: lodPos - modelPos})
+	write(modelLODHeader{NumMeshes: 1, MeshOffset: meshPos - lodPos})
+	write(meshHeader{NumStripGroups: 1, StripGroupHeaderOffset: stripGroupPos - meshPos})
+	write(stripGroupHeader{
+		NumVerts:    numVerts,
+		VertOffset:  vertexPos - stripGroupPos,
+		NumIndices:  numIndices,
+		IndexOffset: indexPos - stripGroupPos,
+		NumStrips:   numStrips,
+		StripOffset: stripPos - stripGroupPos,
+		Flags:       StripGroupIsHWSkinned,
+	})
+	if layout == StripGroupLayoutExtended {
+		write(stripGroupTopology{})
+	}
+	for i := int32(0); i < numStrips; i++ {
+		pos := stripPos + i*stripSize
+		write(stripHeader{
+			NumIndices:            3,
+			IndexOffset:           i * 3,
+			NumVerts:              3,
+			VertOffset:            i,
+			NumBones:              1,
+			Flags:                 1,
+			NumBoneStateChanges:   1,
+			BoneStateChangeOffset: boneStatePos + i*8 - pos,
+		})
+		if layout == StripGroupLayoutExtended {
+			write(stripGroupTopology{NumTopologyIndices: 10 + i, TopologyOffset: 20 + i})
+		}
+	}
+	for i := 0; i < numVerts; i++ {
+		write(Vertex{NumBones: 1, OriginalMeshVertexID: uint16(i)})
+	}
+	write([]uint16{0, 1, 2, 1, 2, 3})
+	write([]BoneStateChange{{HardwareID: 0, NewBoneID: 5}, {HardwareID: 0, NewBoneID: 7}})
+
+	return buf.Bytes()
+}
+
+func TestReadStripLayouts(t *testing.T) {
+	cases := []struct {
+		name       string
+		order      binary.ByteOrder
+		layout     StripGroupLayout
+		mdlVersion int32
+		forced     StripGroupLayout
+	}{
+		{"standard detected", binary.LittleEndian, StripGroupLayoutStandard, 48, StripGroupLayoutAuto},
+		{"standard v49 fallback", binary.LittleEndian, StripGroupLayoutStandard, 49, StripGroupLayoutAuto},
+		{"extended v49", binary.LittleEndian, StripGroupLayoutExtended, 49, StripGroupLayoutAuto},
+		{"extended forced", binary.LittleEndian, StripGroupLayoutExtended, 0, StripGroupLayoutExtended},
+		{"extended big endian", binary.BigEndian, StripGroupLayoutExtended, 49, StripGroupLayoutAuto},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewReader()
+			reader.MdlVersion = tc.mdlVersion
+			reader.StripGroupLayout = tc.forced
+
+			out, err := reader.Read(bytes.NewReader(buildStripFixture(t, tc.order, tc.layout)))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if out.StripGroupLayout != tc.layout {
+				t.Fatalf("layout = %d, want %d", out.StripGroupLayout, tc.layout)
+			}
+			if out.CheckSum != 1234 {
+				t.Errorf("checksum = %d, want 1234", out.CheckSum)
+			}
+
+			stripGroup := out.BodyParts[0].Models[0].LODS[0].Meshes[0].StripGroups[0]
+			if len(stripGroup.Vertexes) != 4 || len(stripGroup.Indices) != 6 || len(stripGroup.Strips) != 2 {
+				t.Fatalf("got %d vertices, %d indices, %d strips", len(stripGroup.Vertexes), len(stripGroup.Indices), len(stripGroup.Strips))
+			}
+
+			for i, strip := range stripGroup.Strips {
+				if strip.IndexOffset != int32(i*3) || strip.NumIndices != 3 || strip.VertOffset != int32(i) {
+					t.Errorf("strip %d ranges = %+v", i, strip)
+				}
+				if len(strip.BoneStateChanges) != 1 {
+					t.Fatalf("strip %d has %d bone state changes, want 1", i, len(strip.BoneStateChanges))
+				}
+				wantBone := []int32{5, 7}[i]
+				if strip.BoneStateChanges[0].NewBoneID != wantBone {
+					t.Errorf("strip %d bone = %d, want %d", i, strip.BoneStateChanges[0].NewBoneID, wantBone)
+				}
+
+				var wantCount, wantOffset int32
+				if tc.layout == StripGroupLayoutExtended {
+					wantCount, wantOffset = int32(10+i), int32(20+i)
+				}
+				if strip.NumTopologyIndices != wantCount || strip.TopologyOffset != wantOffset {
+					t.Errorf("strip %d topology = %d@%d, want %d@%d", i, strip.NumTopologyIndices, strip.TopologyOffset, wantCount, wantOffset)
+				}
+			}
+
+			if table := stripGroup.BoneTable(1); table[0] != 7 {
+				t.Errorf("bone table for strip 1 = %v, want slot 0 bound to 7", table)
+			}
+		})
+	}
+}
+
+func TestReadRejectsBadStrips(t *testing.T) {
+	buf := buildStripFixture(t, binary.LittleEndian, StripGroupLayoutStandard)
+	// Point the first strip's bone state changes past the end of the file
+	stripPos := 36 + 8 + 8 + 12 + 9 + 25
+	binary.LittleEndian.PutUint32(buf[stripPos+23:], 1<<20)
+
+	reader := NewReader()
+	reader.StripGroupLayout = StripGroupLayoutStandard
+	if _, err := reader.Read(bytes.NewReader(buf)); err == nil {
+		t.Fatal("expected an error for out of range bone state changes")
+	}
+}
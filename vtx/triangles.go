@@ -0,0 +1,102 @@
+package vtx
+
+const (
+	// StripIsTriList
+	StripIsTriList = 0x01
+	// StripIsTriStrip
+	StripIsTriStrip = 0x02
+)
+
+// Triangles returns the strip group as a triangle list. Indices point into Vertexes.
+// Strips are unrolled with alternating triangles re-wound to match the first, and
+// degenerate triangles used to stitch strips together are dropped.
+// Groups without strip descriptions are treated as a single triangle list.
+func (stripGroup *StripGroup) Triangles() [][3]uint16 {
+	if len(stripGroup.Strips) == 0 {
+		return appendTriangleList(nil, stripGroup.Indices)
+	}
+
+	triangles := make([][3]uint16, 0, len(stripGroup.Indices)/3)
+	for _, strip := range stripGroup.Strips {
+		from, to := int(strip.IndexOffset), int(strip.IndexOffset)+int(strip.NumIndices)
+		if from < 0 || from > to || to > len(stripGroup.Indices) {
+			continue
+		}
+		indices := stripGroup.Indices[from:to]
+
+		if strip.Flags&StripIsTriStrip != 0 {
+			triangles = appendTriangleStrip(triangles, indices)
+		} else {
+			triangles = appendTriangleList(triangles, indices)
+		}
+	}
+
+	return triangles
+}
+
+// MeshTriangles returns the strip group as a triangle list of mesh vertex IDs
+// (OriginalMeshVertexID), which index the mesh's vvd vertices for this LOD.
+// Triangles referencing vertices outside the strip group are dropped.
+func (stripGroup *StripGroup) MeshTriangles() [][3]uint16 {
+	local := stripGroup.Triangles()
+	triangles := make([][3]uint16, 0, len(local))
+	for _, triangle := range local {
+		if int(triangle[0]) >= len(stripGroup.Vertexes) ||
+			int(triangle[1]) >= len(stripGroup.Vertexes) ||
+			int(triangle[2]) >= len(stripGroup.Vertexes) {
+			continue
+		}
+		triangles = append(triangles, [3]uint16{
+			stripGroup.Vertexes[triangle[0]].OriginalMeshVertexID,
+			stripGroup.Vertexes[triangle[1]].OriginalMeshVertexID,
+			stripGroup.Vertexes[triangle[2]].OriginalMeshVertexID,
+		})
+	}
+	return triangles
+}
+
+// Triangles returns every strip group of the mesh as one triangle list of mesh vertex IDs.
+// See StripGroup.MeshTriangles.
+func (mesh *Mesh) Triangles() [][3]uint16 {
+	triangles := make([][3]uint16, 0)
+	for i := range mesh.StripGroups {
+		triangles = append(triangles, mesh.StripGroups[i].MeshTriangles()...)
+	}
+	return triangles
+}
+
+// FlipWinding reverses the winding order of triangles in place.
+// Source treats clockwise triangles as front facing; use this for counter-clockwise renderers.
+func FlipWinding(triangles [][3]uint16) {
+	for i := range triangles {
+		triangles[i][1], triangles[i][2] = triangles[i][2], triangles[i][1]
+	}
+}
+
+// appendTriangleList appends consecutive index triples, ignoring a trailing partial triangle
+func appendTriangleList(triangles [][3]uint16, indices []uint16) [][3]uint16 {
+	for i := 0; i+2 < len(indices); i += 3 {
+		triangles = appendTriangle(triangles, [3]uint16{indices[i], indices[i+1], indices[i+2]})
+	}
+	return triangles
+}
+
+// appendTriangleStrip unrolls a triangle strip, swapping every odd triangle to keep winding consistent
+func appendTriangleStrip(triangles [][3]uint16, indices []uint16) [][3]uint16 {
+	for i := 0; i+2 < len(indices); i++ {
+		if i%2 == 0 {
+			triangles = appendTriangle(triangles, [3]uint16{indices[i], indices[i+1], indices[i+2]})
+		} else {
+			triangles = appendTriangle(triangles, [3]uint16{indices[i+1], indices[i], indices[i+2]})
+		}
+	}
+	return triangles
+}
+
+// appendTriangle appends a triangle unless it is degenerate
+func appendTriangle(triangles [][3]uint16, triangle [3]uint16) [][3]uint16 {
+	if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[0] == triangle[2] {
+		return triangles
+	}
+	return append(triangles, triangle)
+}
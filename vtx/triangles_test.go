@@ -0,0 +1,120 @@
+package vtx
+
+import (
+	"slices"
+	"testing"
+)
+
+// stripGroup returns a strip group over indices whose vertex i maps to mesh vertex 10+i
+func stripGroup(numVertices int, indices []uint16, strips ...Strip) StripGroup {
+	group := StripGroup{Indices: indices, Strips: strips, Vertexes: make([]Vertex, numVertices)}
+	for i := range group.Vertexes {
+		group.Vertexes[i].OriginalMeshVertexID = uint16(10 + i)
+	}
+	return group
+}
+
+func TestStripGroupTriangles(t *testing.T) {
+	cases := []struct {
+		name  string
+		group StripGroup
+		want  [][3]uint16
+		// mesh is the expected MeshTriangles
+		mesh [][3]uint16
+	}{
+		{
+			name:  "no strips is a list",
+			group: stripGroup(4, []uint16{0, 1, 2, 2, 1, 3}),
+			want:  [][3]uint16{{0, 1, 2}, {2, 1, 3}},
+			mesh:  [][3]uint16{{10, 11, 12}, {12, 11, 13}},
+		},
+		{
+			name:  "list ignores a partial triangle",
+			group: stripGroup(4, []uint16{0, 1, 2, 3, 1}, Strip{NumIndices: 5, Flags: StripIsTriList}),
+			want:  [][3]uint16{{0, 1, 2}},
+			mesh:  [][3]uint16{{10, 11, 12}},
+		},
+		{
+			name:  "strip swaps odd triangles",
+			group: stripGroup(5, []uint16{0, 1, 2, 3, 4}, Strip{NumIndices: 5, Flags: StripIsTriStrip}),
+			want:  [][3]uint16{{0, 1, 2}, {2, 1, 3}, {2, 3, 4}},
+			mesh:  [][3]uint16{{10, 11, 12}, {12, 11, 13}, {12, 13, 14}},
+		},
+		{
+			name:  "same indices as a list",
+			group: stripGroup(5, []uint16{0, 1, 2, 3, 4}, Strip{NumIndices: 5, Flags: StripIsTriList}),
+			want:  [][3]uint16{{0, 1, 2}},
+			mesh:  [][3]uint16{{10, 11, 12}},
+		},
+		{
+			// Two strips stitched by repeating 3 and 4 produce four degenerate triangles
+			name:  "strip drops degenerates",
+			group: stripGroup(7, []uint16{0, 1, 2, 3, 3, 4, 4, 5, 6}, Strip{NumIndices: 9, Flags: StripIsTriStrip}),
+			want:  [][3]uint16{{0, 1, 2}, {2, 1, 3}, {4, 5, 6}},
+			mesh:  [][3]uint16{{10, 11, 12}, {12, 11, 13}, {14, 15, 16}},
+		},
+		{
+			name:  "list drops degenerates",
+			group: stripGroup(4, []uint16{0, 0, 1, 1, 2, 3}),
+			want:  [][3]uint16{{1, 2, 3}},
+			mesh:  [][3]uint16{{11, 12, 13}},
+		},
+		{
+			name: "mixed strips",
+			group: stripGroup(6, []uint16{0, 1, 2, 3, 4, 5, 2},
+				Strip{NumIndices: 3, IndexOffset: 0, Flags: StripIsTriList},
+				Strip{NumIndices: 4, IndexOffset: 3, Flags: StripIsTriStrip}),
+			want: [][3]uint16{{0, 1, 2}, {3, 4, 5}, {5, 4, 2}},
+			mesh: [][3]uint16{{10, 11, 12}, {13, 14, 15}, {15, 14, 12}},
+		},
+		{
+			name: "strip past indices is skipped",
+			group: stripGroup(3, []uint16{0, 1, 2},
+				Strip{NumIndices: 3, IndexOffset: 0, Flags: StripIsTriList},
+				Strip{NumIndices: 3, IndexOffset: 2, Flags: StripIsTriList}),
+			want: [][3]uint16{{0, 1, 2}},
+			mesh: [][3]uint16{{10, 11, 12}},
+		},
+		{
+			name:  "vertex outside group",
+			group: stripGroup(3, []uint16{0, 1, 2, 0, 2, 3}),
+			want:  [][3]uint16{{0, 1, 2}, {0, 2, 3}},
+			mesh:  [][3]uint16{{10, 11, 12}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.group.Triangles(); !slices.Equal(got, tc.want) {
+				t.Errorf("Triangles = %v, want %v", got, tc.want)
+			}
+			if got := tc.group.MeshTriangles(); !slices.Equal(got, tc.mesh) {
+				t.Errorf("MeshTriangles = %v, want %v", got, tc.mesh)
+			}
+		})
+	}
+}
+
+func TestMeshTriangles(t *testing.T) {
+	mesh := Mesh{StripGroups: []StripGroup{
+		stripGroup(3, []uint16{0, 1, 2}),
+		stripGroup(4, []uint16{0, 1, 2, 3}, Strip{NumIndices: 4, Flags: StripIsTriStrip}),
+		stripGroup(0, nil),
+	}}
+
+	want := [][3]uint16{{10, 11, 12}, {10, 11, 12}, {12, 11, 13}}
+	triangles := mesh.Triangles()
+	if !slices.Equal(triangles, want) {
+		t.Fatalf("Triangles = %v, want %v", triangles, want)
+	}
+
+	FlipWinding(triangles)
+	flipped := [][3]uint16{{10, 12, 11}, {10, 12, 11}, {12, 13, 11}}
+	if !slices.Equal(triangles, flipped) {
+		t.Errorf("flipped = %v, want %v", triangles, flipped)
+	}
+
+	if empty := (&Mesh{}).Triangles(); empty == nil || len(empty) != 0 {
+		t.Errorf("mesh without strip groups = %#v, want an empty list", empty)
+	}
+}
@@ -1,9 +1,50 @@
 package vtx
 
+import "encoding/binary"
+
 // Vtx
 type Vtx struct {
+	// NumLODs
+	NumLODs int32
+	// CheckSum
+	// Must match the checksum of the mdl this vtx belongs to
+	CheckSum int32
 	// BodyParts
 	BodyParts []BodyPart
+	// ByteOrder is the byte order the file was stored in.
+	// binary.BigEndian for Xbox 360 and PS3 (.360.vtx) files
+	ByteOrder binary.ByteOrder
+	// StripGroupLayout is the strip group header layout the file was read with
+	StripGroupLayout StripGroupLayout
+	// MaterialReplacements
+	// Per LOD list of materials replaced by $lod replacematerial
+	MaterialReplacements [][]MaterialReplacement
+	// Variant is the file variant this vtx was loaded from.
+	// Only set by Loader
+	Variant Variant
+}
+
+// MaterialReplacement swaps an mdl material for another at a particular LOD
+type MaterialReplacement struct {
+	// MaterialID
+	// Index into the mdl's textures
+	MaterialID int16
+	// Name
+	// Name of the replacement material
+	Name string
+}
+
+// ReplacementMaterial returns the name of the material that replaces materialID at a LOD, if any
+func (vtx *Vtx) ReplacementMaterial(lod int, materialID int) (string, bool) {
+	if lod < 0 || lod >= len(vtx.MaterialReplacements) {
+		return "", false
+	}
+	for _, replacement := range vtx.MaterialReplacements[lod] {
+		if int(replacement.MaterialID) == materialID {
+			return replacement.Name, true
+		}
+	}
+	return "", false
 }
 
 // BodyPart
@@ -22,12 +63,29 @@ type Model struct {
 type ModelLOD struct {
 	// Meshes
 	Meshes []Mesh
+	// SwitchPoint
+	// LOD metric at which this LOD becomes active. Negative for a shadow LOD
+	SwitchPoint float32
 }
 
+// IsShadowLOD returns whether this LOD is only used for rendering shadows ($shadowlod)
+func (lod *ModelLOD) IsShadowLOD() bool {
+	return lod.SwitchPoint < 0
+}
+
+const (
+	// MeshIsTeeth
+	MeshIsTeeth = 0x01
+	// MeshIsEyes
+	MeshIsEyes = 0x02
+)
+
 // Mesh
 type Mesh struct {
 	// StripGroups
 	StripGroups []StripGroup
+	// Flags
+	Flags uint8
 }
 
 // StripGroup
@@ -38,6 +96,11 @@ type StripGroup struct {
 	Vertexes []Vertex
 	// Strips
 	Strips []Strip
+	// Topology
+	// Only present in files using StripGroupLayoutExtended
+	Topology []uint16
+	// Flags
+	Flags uint8
 }
 
 // header
@@ -68,6 +131,23 @@ type header struct {
 	BodyPartOffset int32
 }
 
+// materialReplacementListHeader
+type materialReplacementListHeader struct {
+	// NumReplacements
+	NumReplacements int32
+	// ReplacementOffset
+	ReplacementOffset int32
+}
+
+// materialReplacementHeader
+// Packed to 6 bytes on disk
+type materialReplacementHeader struct {
+	// MaterialID
+	MaterialID int16
+	// ReplacementMaterialNameOffset
+	ReplacementMaterialNameOffset int32
+}
+
 // bodyPartHeader
 type bodyPartHeader struct {
 	// NumModels
@@ -138,6 +218,47 @@ type stripGroupHeader struct {
 	//_     [3]byte
 }
 
+// stripGroupTopology follows stripGroupHeader and stripHeader in StripGroupLayoutExtended files
+type stripGroupTopology struct {
+	// NumTopologyIndices
+	NumTopologyIndices int32
+	// TopologyOffset
+	TopologyOffset int32
+}
+
+// StripGroupLayout is the on-disk layout of strip group and strip headers.
+// Later Source branches (L4D2, CS:GO and up) append topology fields to both.
+type StripGroupLayout int
+
+const (
+	// StripGroupLayoutAuto detects the layout from the mdl version and the file contents
+	StripGroupLayoutAuto StripGroupLayout = iota
+	// StripGroupLayoutStandard is the original 25 byte header
+	StripGroupLayoutStandard
+	// StripGroupLayoutExtended is the 33 byte header including topology, with 35 byte strip headers
+	StripGroupLayoutExtended
+)
+
+// extendedStripGroupMinMdlVersion is the first mdl version whose vtx may use the extended layout
+const extendedStripGroupMinMdlVersion = 49
+
+// headerSize returns the packed size of a strip group header in this layout.
+// VTX ignores trailing byte 4-byte alignment
+func (layout StripGroupLayout) headerSize() int32 {
+	if layout == StripGroupLayoutExtended {
+		return 33
+	}
+	return 25
+}
+
+// stripHeaderSize returns the packed size of a strip header in this layout
+func (layout StripGroupLayout) stripHeaderSize() int32 {
+	if layout == StripGroupLayoutExtended {
+		return 35
+	}
+	return 27
+}
+
 // Strip
 type Strip struct {
 	// NumIndices
@@ -161,6 +282,99 @@ type Strip struct {
 	NumBoneStateChanges int32
 	//BoneStateChangeOffset
 	BoneStateChangeOffset int32
+
+	// NumTopologyIndices
+	// Only set in files using StripGroupLayoutExtended
+	NumTopologyIndices int32
+	// TopologyOffset
+	// Only set in files using StripGroupLayoutExtended
+	TopologyOffset int32
+
+	// BoneStateChanges
+	// Hardware bone slots that are rebound before this strip is drawn
+	BoneStateChanges []BoneStateChange
+}
+
+// stripHeader is the on-disk layout of a Strip
+type stripHeader struct {
+	// NumIndices
+	NumIndices int32
+	// IndexOffset
+	IndexOffset int32
+
+	// NumVerts
+	NumVerts int32
+	// VertOffset
+	VertOffset int32
+
+	// NumBones
+	NumBones int16
+
+	// Flags
+	Flags uint8
+
+	//NumBoneStateChanges
+	NumBoneStateChanges int32
+	//BoneStateChangeOffset
+	BoneStateChangeOffset int32
+}
+
+// BoneStateChange binds a model bone to a hardware bone slot
+type BoneStateChange struct {
+	// HardwareID
+	// Hardware bone slot, as referenced by Vertex.BoneID
+	HardwareID int32
+	// NewBoneID
+	// Model bone index
+	NewBoneID int32
+}
+
+// BoneTable returns the hardware slot to model bone mapping in effect while a strip is drawn.
+// Bone state changes accumulate across the strips of a strip group, so every earlier strip's
+// changes are applied first.
+func (stripGroup *StripGroup) BoneTable(strip int) map[int32]int32 {
+	table := make(map[int32]int32)
+	for i := 0; i <= strip && i < len(stripGroup.Strips); i++ {
+		for _, change := range stripGroup.Strips[i].BoneStateChanges {
+			table[change.HardwareID] = change.NewBoneID
+		}
+	}
+	return table
+}
+
+// ModelBoneID translates a strip local (hardware) bone ID into a model bone index.
+// IDs without a bone state change are already model bone indices. Negative IDs are unused
+// influences and are returned unchanged.
+func (stripGroup *StripGroup) ModelBoneID(strip int, boneID int8) int32 {
+	if boneID < 0 {
+		return int32(boneID)
+	}
+	for i := strip; i >= 0; i-- {
+		if i >= len(stripGroup.Strips) {
+			continue
+		}
+		changes := stripGroup.Strips[i].BoneStateChanges
+		for j := len(changes) - 1; j >= 0; j-- {
+			if changes[j].HardwareID == int32(boneID) {
+				return changes[j].NewBoneID
+			}
+		}
+	}
+	return int32(boneID)
+}
+
+// VertexModelBones returns the model bone indices of a strip group vertex's influences, as
+// drawn by a strip. Unused influences are -1.
+func (stripGroup *StripGroup) VertexModelBones(strip int, vertex int) [3]int32 {
+	bones := [3]int32{-1, -1, -1}
+	if vertex < 0 || vertex >= len(stripGroup.Vertexes) {
+		return bones
+	}
+	v := &stripGroup.Vertexes[vertex]
+	for i := 0; i < int(v.NumBones) && i < len(bones); i++ {
+		bones[i] = stripGroup.ModelBoneID(strip, v.BoneID[i])
+	}
+	return bones
 }
 
 // Vertex
@@ -176,3 +390,35 @@ type Vertex struct {
 	// BoneID
 	BoneID [3]int8
 }
+
+// NumRenderLODs returns the number of LODs usable for regular rendering, excluding a shadow LOD
+func (model *Model) NumRenderLODs() int {
+	num := len(model.LODS)
+	if num > 0 && model.LODS[num-1].IsShadowLOD() {
+		num--
+	}
+	return num
+}
+
+// LODForMetric returns the LOD to render for an LOD metric (larger is further away),
+// never returning a LOD more detailed than rootLOD.
+// Mirrors the engine's switch point walk.
+func (model *Model) LODForMetric(metric float32, rootLOD int) int {
+	numLODs := model.NumRenderLODs()
+	if numLODs == 0 {
+		return 0
+	}
+	if rootLOD < 0 {
+		rootLOD = 0
+	}
+	if rootLOD > numLODs-1 {
+		rootLOD = numLODs - 1
+	}
+
+	for i := rootLOD; i < numLODs-1; i++ {
+		if model.LODS[i+1].SwitchPoint > metric {
+			return i
+		}
+	}
+	return numLODs - 1
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"github.com/galaco/studiomodel/internal"
 	"github.com/go-gl/mathgl/mgl32"
 	"io"
 	"unsafe"
@@ -18,6 +19,7 @@ const (
 
 // Reader
 type Reader struct {
+	byteOrder binary.ByteOrder
 }
 
 // Read parses a stream to a Vvd struct
@@ -31,8 +33,14 @@ func (reader *Reader) Read(stream io.Reader) (*Vvd, error) {
 	buf = byteBuf.Bytes()
 
 	// Validate minimum file size
-	if len(buf) < int(unsafe.Sizeof(header{})) {
-		return nil, fmt.Errorf("vvd file too small: %d bytes, expected at least %d", len(buf), unsafe.Sizeof(header{}))
+	if len(buf) < int(unsafe.Sizeof(Header{})) {
+		return nil, fmt.Errorf("vvd file too small: %d bytes, expected at least %d", len(buf), unsafe.Sizeof(Header{}))
+	}
+
+	// Console builds are byte swapped; the magic number tells us which order we have
+	reader.byteOrder, err = internal.DetectByteOrder(buf, VVDMagicNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VVD magic number: %w", err)
 	}
 
 	offset := 0
@@ -79,39 +87,53 @@ func (reader *Reader) Read(stream io.Reader) (*Vvd, error) {
 	}
 
 	//Read tangents
-	tangents, _, err := reader.readTangents(buf, int(header.TangentDataStart), &header)
+	tangents, offset, err := reader.readTangents(buf, int(header.TangentDataStart), &header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read VVD tangents at offset %d: %w", header.TangentDataStart, err)
 	}
 
+	// Read extra data (CS:GO and later)
+	extraData, err := reader.readExtraData(buf, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VVD extra data at offset %d: %w", offset, err)
+	}
+
+	extraTexCoords, err := reader.decodeExtraTexCoords(extraData, len(vertices))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode VVD extra texture coordinates: %w", err)
+	}
+
 	return &Vvd{
-		Header:   header,
-		Fixups:   fixups,
-		Vertices: vertices,
-		Tangents: tangents,
+		Header:         header,
+		Fixups:         fixups,
+		Vertices:       vertices,
+		Tangents:       tangents,
+		ExtraData:      extraData,
+		ExtraTexCoords: extraTexCoords,
+		ByteOrder:      reader.byteOrder,
 	}, nil
 }
 
 // Reads studiohdr header information
-func (reader *Reader) readHeader(buf []byte, offset int) (header, int, error) {
-	header := header{}
+func (reader *Reader) readHeader(buf []byte, offset int) (Header, int, error) {
+	header := Header{}
 	headerSize := unsafe.Sizeof(header)
 
-	err := binary.Read(bytes.NewBuffer(buf[offset:headerSize]), binary.LittleEndian, &header)
+	err := binary.Read(bytes.NewBuffer(buf[offset:headerSize]), reader.byteOrder, &header)
 
 	return header, int(headerSize), err
 }
 
-func (reader *Reader) readFixups(buf []byte, offset int, numFixups int) ([]fixup, int, error) {
+func (reader *Reader) readFixups(buf []byte, offset int, numFixups int) ([]Fixup, int, error) {
 	if numFixups < 0 {
 		return nil, 0, fmt.Errorf("invalid negative fixup count: %d", numFixups)
 	}
 
 	if numFixups == 0 {
-		return []fixup{}, offset, nil
+		return []Fixup{}, offset, nil
 	}
 
-	fixupSize := int(unsafe.Sizeof(fixup{}))
+	fixupSize := int(unsafe.Sizeof(Fixup{}))
 
 	// Validate buffer bounds
 	requiredSize := offset + (fixupSize * numFixups)
@@ -119,8 +141,8 @@ func (reader *Reader) readFixups(buf []byte, offset int, numFixups int) ([]fixup
 		return nil, 0, fmt.Errorf("fixup data exceeds buffer: need %d bytes, have %d", requiredSize, len(buf))
 	}
 
-	fixups := make([]fixup, numFixups)
-	err := binary.Read(bytes.NewBuffer(buf[offset:offset+(fixupSize*numFixups)]), binary.LittleEndian, &fixups)
+	fixups := make([]Fixup, numFixups)
+	err := binary.Read(bytes.NewBuffer(buf[offset:offset+(fixupSize*numFixups)]), reader.byteOrder, &fixups)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -129,8 +151,8 @@ func (reader *Reader) readFixups(buf []byte, offset int, numFixups int) ([]fixup
 }
 
 // read vertex data
-func (reader *Reader) readVertices(buf []byte, offset int, header *header) ([]vertex, int, error) {
-	vertexSize := int(unsafe.Sizeof(vertex{}))
+func (reader *Reader) readVertices(buf []byte, offset int, header *Header) ([]Vertex, int, error) {
+	vertexSize := int(unsafe.Sizeof(Vertex{}))
 
 	// Calculate the actual number of vertices stored in the file by using byte offsets
 	// The vertices are stored from VertexDataStart to TangentDataStart
@@ -155,21 +177,21 @@ func (reader *Reader) readVertices(buf []byte, offset int, header *header) ([]ve
 		return nil, 0, fmt.Errorf("vertex data exceeds buffer: need %d bytes, have %d", requiredSize, len(buf))
 	}
 
-	vertexes := make([]vertex, numVertices)
-	err := binary.Read(bytes.NewBuffer(buf[offset:offset+vertexDataLength]), binary.LittleEndian, &vertexes)
+	vertexes := make([]Vertex, numVertices)
+	err := binary.Read(bytes.NewBuffer(buf[offset:offset+vertexDataLength]), reader.byteOrder, &vertexes)
 
 	return vertexes, offset + vertexDataLength, err
 }
 
 // read tangent data
 // NOTE: There is 1 tangent for every vertex
-func (reader *Reader) readTangents(buf []byte, offset int, header *header) ([]mgl32.Vec4, int, error) {
+func (reader *Reader) readTangents(buf []byte, offset int, header *Header) ([]mgl32.Vec4, int, error) {
 	tangentSize := int(unsafe.Sizeof(mgl32.Vec4{}))
 
 	// Calculate number of tangents from vertex count (1 tangent per vertex)
 	// Tangents are stored from TangentDataStart to end of vertex data
 	vertexDataLength := int(header.TangentDataStart - header.VertexDataStart)
-	vertexSize := int(unsafe.Sizeof(vertex{}))
+	vertexSize := int(unsafe.Sizeof(Vertex{}))
 	numTangents := vertexDataLength / vertexSize
 
 	// Calculate tangent data length
@@ -182,11 +204,89 @@ func (reader *Reader) readTangents(buf []byte, offset int, header *header) ([]mg
 	}
 
 	tangents := make([]mgl32.Vec4, numTangents)
-	err := binary.Read(bytes.NewBuffer(buf[offset:offset+tangentDataLength]), binary.LittleEndian, &tangents)
+	err := binary.Read(bytes.NewBuffer(buf[offset:offset+tangentDataLength]), reader.byteOrder, &tangents)
 
 	return tangents, offset + tangentDataLength, err
 }
 
+// readExtraData reads the extra vertex attribute table that follows the tangents in
+// CS:GO era files. Older files end after the tangents, so no table is not an error.
+func (reader *Reader) readExtraData(buf []byte, offset int) ([]ExtraAttribute, error) {
+	headerSize := int(unsafe.Sizeof(extraDataHeader{}))
+	if offset+headerSize > len(buf) {
+		return nil, nil
+	}
+
+	header := extraDataHeader{}
+	if err := binary.Read(bytes.NewBuffer(buf[offset:offset+headerSize]), reader.byteOrder, &header); err != nil {
+		return nil, err
+	}
+	if header.Count <= 0 {
+		return nil, nil
+	}
+
+	indexSize := int(unsafe.Sizeof(extraDataIndex{}))
+	indexStart := offset + headerSize
+	if indexStart+indexSize*int(header.Count) > len(buf) {
+		return nil, fmt.Errorf("extra data index exceeds buffer: %d entries at offset %d, have %d bytes", header.Count, indexStart, len(buf))
+	}
+
+	indices := make([]extraDataIndex, header.Count)
+	if err := binary.Read(bytes.NewBuffer(buf[indexStart:indexStart+indexSize*int(header.Count)]), reader.byteOrder, &indices); err != nil {
+		return nil, err
+	}
+
+	attributes := make([]ExtraAttribute, len(indices))
+	for i, index := range indices {
+		if index.Offset < 0 || index.Bytes < 0 {
+			return nil, fmt.Errorf("extra attribute %d has negative offset or size", i)
+		}
+		// Offsets are relative to the start of the file. Fall back to the extra data
+		// header for files that were written relative to it.
+		start := int(index.Offset)
+		if start+int(index.Bytes) > len(buf) || start < indexStart {
+			start = offset + int(index.Offset)
+		}
+		if start+int(index.Bytes) > len(buf) {
+			return nil, fmt.Errorf("extra attribute %d data exceeds buffer: offset=%d size=%d bufferSize=%d", i, index.Offset, index.Bytes, len(buf))
+		}
+
+		attributes[i] = ExtraAttribute{
+			Type: ExtraAttributeType(index.Type),
+			Data: buf[start : start+int(index.Bytes)],
+		}
+	}
+
+	return attributes, nil
+}
+
+// decodeExtraTexCoords decodes texture coordinate attributes into per vertex channels
+func (reader *Reader) decodeExtraTexCoords(attributes []ExtraAttribute, numVertices int) (map[int][]mgl32.Vec2, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	texCoordSize := int(unsafe.Sizeof(mgl32.Vec2{}))
+	channels := make(map[int][]mgl32.Vec2)
+	for _, attribute := range attributes {
+		if attribute.Type < ExtraAttributeTexCoord0 || attribute.Type > ExtraAttributeTexCoord7 {
+			continue
+		}
+		if len(attribute.Data) != numVertices*texCoordSize {
+			return nil, fmt.Errorf("texture coordinate channel %d has %d bytes, expected %d for %d vertices",
+				attribute.Type, len(attribute.Data), numVertices*texCoordSize, numVertices)
+		}
+
+		texCoords := make([]mgl32.Vec2, numVertices)
+		if err := binary.Read(bytes.NewBuffer(attribute.Data), reader.byteOrder, &texCoords); err != nil {
+			return nil, err
+		}
+		channels[int(attribute.Type-ExtraAttributeTexCoord0)] = texCoords
+	}
+
+	return channels, nil
+}
+
 // NewReader returns a new reader
 func NewReader() *Reader {
 	return new(Reader)
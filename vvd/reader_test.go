@@ -0,0 +1,174 @@
+package vvd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// extraChannel is an extra data entry to append to a test vvd
+type extraChannel struct {
+	channel int32
+	// headerRelative writes the offset relative to the extra data header instead of the file
+	headerRelative bool
+	texCoords      []mgl32.Vec2
+}
+
+// buildVvd writes a single LOD vvd with 3 vertices, followed by an extra data table
+// when channels are given
+func buildVvd(t *testing.T, order binary.ByteOrder, channels ...extraChannel) []byte {
+	t.Helper()
+
+	headerSize := int32(unsafe.Sizeof(Header{}))
+	vertexSize := int32(unsafe.Sizeof(Vertex{}))
+	vertices := []Vertex{
+		NewVertex(mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec2{0, 0}, NewRigidBoneWeight(0)),
+		NewVertex(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec2{1, 0}, NewRigidBoneWeight(0)),
+		NewVertex(mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 0, 1}, mgl32.Vec2{0, 1}, NewRigidBoneWeight(0)),
+	}
+	tangents := []mgl32.Vec4{{1, 0, 0, 1}, {1, 0, 0, 1}, {1, 0, 0, 1}}
+
+	header := Header{
+		Id:               VVDMagicNumber,
+		Version:          VVDVersion,
+		Checksum:         55,
+		NumLODs:          1,
+		NumLODVertexes:   [MaxNumLods]int32{3},
+		FixupTableStart:  headerSize,
+		VertexDataStart:  headerSize,
+		TangentDataStart: headerSize + vertexSize*int32(len(vertices)),
+	}
+
+	buf := &bytes.Buffer{}
+	for _, data := range []any{header, vertices, tangents} {
+		if err := binary.Write(buf, order, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(channels) == 0 {
+		return buf.Bytes()
+	}
+
+	extraStart := int32(buf.Len())
+	dataStart := int32(unsafe.Sizeof(extraDataHeader{})) + int32(unsafe.Sizeof(extraDataIndex{}))*int32(len(channels))
+	indices := make([]extraDataIndex, len(channels))
+	data := &bytes.Buffer{}
+	for i, channel := range channels {
+		offset := dataStart + int32(data.Len())
+		if !channel.headerRelative {
+			offset += extraStart
+		}
+		indices[i] = extraDataIndex{Type: channel.channel, Offset: offset, Bytes: int32(len(channel.texCoords) * 8)}
+		if err := binary.Write(data, order, channel.texCoords); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extraHeader := extraDataHeader{Count: int32(len(channels)), TotalBytes: int32(data.Len())}
+	for _, value := range []any{extraHeader, indices} {
+		if err := binary.Write(buf, order, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func TestReadExtraTexCoords(t *testing.T) {
+	lightmap := []mgl32.Vec2{{0.5, 0.5}, {0.25, 0.75}, {1, 1}}
+	detail := []mgl32.Vec2{{2, 2}, {3, 3}, {4, 4}}
+
+	cases := []struct {
+		name         string
+		order        binary.ByteOrder
+		channels     []extraChannel
+		wantChannels int
+		want         map[int][]mgl32.Vec2
+	}{
+		{"no extra data", binary.LittleEndian, nil, 1, nil},
+		{"file relative", binary.LittleEndian, []extraChannel{{channel: 1, texCoords: lightmap}}, 2, map[int][]mgl32.Vec2{1: lightmap}},
+		{"header relative", binary.LittleEndian, []extraChannel{{channel: 1, headerRelative: true, texCoords: lightmap}}, 2, map[int][]mgl32.Vec2{1: lightmap}},
+		{"big endian", binary.BigEndian, []extraChannel{{channel: 1, texCoords: lightmap}}, 2, map[int][]mgl32.Vec2{1: lightmap}},
+		{"several channels", binary.LittleEndian, []extraChannel{{channel: 1, texCoords: lightmap}, {channel: 3, texCoords: detail}}, 4, map[int][]mgl32.Vec2{1: lightmap, 3: detail}},
+		{"overrides base channel", binary.LittleEndian, []extraChannel{{channel: 0, texCoords: detail}}, 1, map[int][]mgl32.Vec2{0: detail}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := ReadFromStream(bytes.NewReader(buildVvd(t, tc.order, tc.channels...)))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if len(out.Vertices) != 3 || len(out.Tangents) != 3 {
+				t.Fatalf("read %d vertices and %d tangents, want 3", len(out.Vertices), len(out.Tangents))
+			}
+			if len(out.ExtraData) != len(tc.channels) {
+				t.Errorf("read %d extra attributes, want %d", len(out.ExtraData), len(tc.channels))
+			}
+			if got := out.NumTexCoordChannels(); got != tc.wantChannels {
+				t.Errorf("NumTexCoordChannels = %d, want %d", got, tc.wantChannels)
+			}
+
+			for channel, want := range tc.want {
+				got, err := out.TexCoordsForLOD(channel, 0)
+				if err != nil {
+					t.Fatalf("TexCoordsForLOD(%d) failed: %v", channel, err)
+				}
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("channel %d vertex %d = %v, want %v", channel, i, got[i], want[i])
+					}
+					if uv, ok := out.TexCoord(channel, i); !ok || uv != want[i] {
+						t.Errorf("TexCoord(%d, %d) = %v, %v", channel, i, uv, ok)
+					}
+				}
+			}
+
+			if _, ok := tc.want[0]; !ok {
+				if uv, ok := out.TexCoord(0, 1); !ok || uv != (mgl32.Vec2{1, 0}) {
+					t.Errorf("base channel = %v, %v, want the vertex UV", uv, ok)
+				}
+			}
+			if _, ok := out.TexCoord(5, 0); ok {
+				t.Error("TexCoord returned a missing channel")
+			}
+		})
+	}
+}
+
+func TestReadRejectsBadExtraData(t *testing.T) {
+	valid := func() []byte {
+		return buildVvd(t, binary.LittleEndian, extraChannel{channel: 1, texCoords: []mgl32.Vec2{{1, 1}, {2, 2}, {3, 3}}})
+	}
+	// The extra data header, one index entry and 3 texture coordinates end the file
+	indexStart := len(valid()) - 12 - 24
+
+	cases := []struct {
+		name string
+		buf  []byte
+	}{
+		{"short channel", buildVvd(t, binary.LittleEndian, extraChannel{channel: 1, texCoords: []mgl32.Vec2{{1, 1}, {2, 2}}})},
+		{"data past end", valid()[:indexStart+12+20]},
+		{"index past end", func() []byte {
+			b := valid()
+			binary.LittleEndian.PutUint32(b[indexStart-8:], 1000)
+			return b
+		}()},
+		{"negative size", func() []byte {
+			b := valid()
+			binary.LittleEndian.PutUint32(b[indexStart+8:], 0xffffffff)
+			return b
+		}()},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadFromStream(bytes.NewReader(tc.buf)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package vvd
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// NewVertex returns a vertex with the passed attributes
+func NewVertex(position mgl32.Vec3, normal mgl32.Vec3, uv mgl32.Vec2, weights BoneWeight) Vertex {
+	return Vertex{
+		BoneWeight: weights,
+		Position:   position,
+		Normal:     normal,
+		UVs:        uv,
+	}
+}
+
+// NewTangentVertex returns a vertex with the passed attributes and tangent.
+// The tangent's W is the bitangent handedness, +1 or -1
+func NewTangentVertex(position mgl32.Vec3, normal mgl32.Vec3, uv mgl32.Vec2, tangent mgl32.Vec4, weights BoneWeight) TangentVertex {
+	return TangentVertex{
+		Vertex:  NewVertex(position, normal, uv, weights),
+		Tangent: tangent,
+	}
+}
+
+// NewBoneWeight returns a BoneWeight from parallel bone and weight lists.
+// At most MaxNumBonesPerVertex influences are allowed.
+func NewBoneWeight(bones []int8, weights []float32) (BoneWeight, error) {
+	if len(bones) != len(weights) {
+		return BoneWeight{}, fmt.Errorf("got %d bones but %d weights", len(bones), len(weights))
+	}
+	if len(bones) > MaxNumBonesPerVertex {
+		return BoneWeight{}, fmt.Errorf("%d bone influences exceeds maximum of %d", len(bones), MaxNumBonesPerVertex)
+	}
+
+	out := BoneWeight{
+		NumBones: int8(len(bones)),
+	}
+	copy(out.Bone[:], bones)
+	copy(out.Weight[:], weights)
+
+	return out, nil
+}
+
+// NewRigidBoneWeight returns a BoneWeight fully attached to a single bone
+func NewRigidBoneWeight(bone int8) BoneWeight {
+	return BoneWeight{
+		Weight:   [MaxNumBonesPerVertex]float32{1},
+		Bone:     [MaxNumBonesPerVertex]int8{bone},
+		NumBones: 1,
+	}
+}
+
+// Influences iterates the bones this weight references, with their weights
+func (weight *BoneWeight) Influences() iter.Seq2[int8, float32] {
+	return func(yield func(int8, float32) bool) {
+		num := int(weight.NumBones)
+		if num > MaxNumBonesPerVertex {
+			num = MaxNumBonesPerVertex
+		}
+		for i := 0; i < num; i++ {
+			if !yield(weight.Bone[i], weight.Weight[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Handedness returns the sign of the bitangent encoded in a tangent's W component (+1 or -1)
+func Handedness(tangent mgl32.Vec4) float32 {
+	if tangent[3] < 0 {
+		return -1
+	}
+	return 1
+}
+
+// Bitangent reconstructs the bitangent of a vertex from its normal and tangent
+func Bitangent(normal mgl32.Vec3, tangent mgl32.Vec4) mgl32.Vec3 {
+	return normal.Cross(tangent.Vec3()).Mul(Handedness(tangent))
+}
+
+// Handedness returns the sign of the vertex's bitangent
+func (vertex *TangentVertex) Handedness() float32 {
+	return Handedness(vertex.Tangent)
+}
+
+// Bitangent reconstructs the vertex's bitangent from its normal and tangent
+func (vertex *TangentVertex) Bitangent() mgl32.Vec3 {
+	return Bitangent(vertex.Normal, vertex.Tangent)
+}
+
+// All iterates every stored vertex. Yielded pointers reference Vertices directly.
+func (vvd *Vvd) All() iter.Seq2[int, *Vertex] {
+	return func(yield func(int, *Vertex) bool) {
+		for i := range vvd.Vertices {
+			if !yield(i, &vvd.Vertices[i]) {
+				return
+			}
+		}
+	}
+}
+
+// AllWithTangents iterates every stored vertex with its tangent.
+// The tangent is zero if the file has no tangent data.
+func (vvd *Vvd) AllWithTangents() iter.Seq2[*Vertex, mgl32.Vec4] {
+	return func(yield func(*Vertex, mgl32.Vec4) bool) {
+		for i := range vvd.Vertices {
+			var tangent mgl32.Vec4
+			if i < len(vvd.Tangents) {
+				tangent = vvd.Tangents[i]
+			}
+			if !yield(&vvd.Vertices[i], tangent) {
+				return
+			}
+		}
+	}
+}
+
+// LOD returns an iterator over the vertices of a LOD and their tangents, in the same
+// order as VerticesForLOD, without building new arrays. Yielded pointers reference
+// Vertices directly. The tangent is zero if the file has no tangent data.
+// The LOD and its fixups are checked up front, so the error is the one VerticesForLOD
+// would return and a valid LOD is always iterated in full.
+func (vvd *Vvd) LOD(lod int) (iter.Seq2[*Vertex, mgl32.Vec4], error) {
+	if err := vvd.validateLOD(lod); err != nil {
+		return nil, err
+	}
+
+	runs := [][2]int{{0, len(vvd.Vertices)}}
+	if len(vvd.Fixups) > 0 {
+		var err error
+		runs, err = fixupRuns(vvd.Fixups, len(vvd.Vertices), lod, int(vvd.Header.NumLODVertexes[lod]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(yield func(*Vertex, mgl32.Vec4) bool) {
+		for _, run := range runs {
+			for i := run[0]; i < run[1]; i++ {
+				var tangent mgl32.Vec4
+				if i < len(vvd.Tangents) {
+					tangent = vvd.Tangents[i]
+				}
+				if !yield(&vvd.Vertices[i], tangent) {
+					return
+				}
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,165 @@
+package vvd
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// lodTestVvd has 5 vertices over two LODs. LOD 0 uses every vertex, LOD 1 drops vertex 2.
+// Each tangent's X matches its vertex's X, and odd vertices are left handed
+func lodTestVvd() *Vvd {
+	vertices := make([]Vertex, 5)
+	tangents := make([]mgl32.Vec4, 5)
+	for i := range vertices {
+		vertices[i] = NewVertex(mgl32.Vec3{float32(i)}, mgl32.Vec3{0, 0, 1}, mgl32.Vec2{}, NewRigidBoneWeight(0))
+		tangents[i] = mgl32.Vec4{float32(i), 0, 0, float32(1 - 2*(i%2))}
+	}
+	return &Vvd{
+		Header: Header{NumLODs: 2, NumLODVertexes: [MaxNumLods]int32{5, 4}},
+		Fixups: []Fixup{
+			{Lod: 1, SourceVertexID: 0, NumVertexes: 2},
+			{Lod: 0, SourceVertexID: 2, NumVertexes: 1},
+			{Lod: 1, SourceVertexID: 3, NumVertexes: 2},
+		},
+		Vertices: vertices,
+		Tangents: tangents,
+	}
+}
+
+func TestLODMatchesVerticesForLOD(t *testing.T) {
+	broken := lodTestVvd()
+	broken.Fixups[2].NumVertexes = 10
+
+	miscounted := lodTestVvd()
+	miscounted.Header.NumLODVertexes[1] = 3
+
+	noFixups := lodTestVvd()
+	noFixups.Fixups = nil
+
+	noTangents := lodTestVvd()
+	noTangents.Tangents = nil
+
+	cases := []struct {
+		name     string
+		file     *Vvd
+		lod      int
+		want     []float32
+		tangents bool
+	}{
+		{"lod 0", lodTestVvd(), 0, []float32{0, 1, 2, 3, 4}, true},
+		{"lod 1", lodTestVvd(), 1, []float32{0, 1, 3, 4}, true},
+		{"no fixups", noFixups, 1, []float32{0, 1, 2, 3, 4}, true},
+		{"no tangents", noTangents, 1, []float32{0, 1, 3, 4}, false},
+		{"negative lod", lodTestVvd(), -1, nil, false},
+		{"lod past header", lodTestVvd(), 2, nil, false},
+		{"lod past maximum", lodTestVvd(), MaxNumLods, nil, false},
+		{"fixup out of range", broken, 1, nil, false},
+		{"fixup count mismatch", miscounted, 1, nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			seq, err := tc.file.LOD(tc.lod)
+			vertices, wantErr := tc.file.VerticesForLOD(tc.lod)
+			if (err == nil) != (wantErr == nil) {
+				t.Fatalf("LOD error = %v, VerticesForLOD error = %v", err, wantErr)
+			}
+			if tc.want == nil {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LOD failed: %v", err)
+			}
+
+			var got []float32
+			for vertex, tangent := range seq {
+				got = append(got, vertex.Position.X())
+				if !tc.tangents {
+					if tangent != (mgl32.Vec4{}) {
+						t.Errorf("vertex %v has tangent %v, want zero", vertex.Position, tangent)
+					}
+					continue
+				}
+				if tangent.X() != vertex.Position.X() {
+					t.Errorf("vertex %v has tangent %v", vertex.Position, tangent)
+				}
+			}
+
+			if len(got) != len(tc.want) || len(vertices) != len(tc.want) {
+				t.Fatalf("LOD yielded %v and VerticesForLOD %d vertices, want %v", got, len(vertices), tc.want)
+			}
+			for i := range tc.want {
+				if got[i] != tc.want[i] || vertices[i].Position.X() != tc.want[i] {
+					t.Errorf("vertex %d = %v / %v, want %v", i, got[i], vertices[i].Position.X(), tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLODStopsEarly(t *testing.T) {
+	seq, err := lodTestVvd().LOD(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	num := 0
+	for range seq {
+		num++
+		if num == 2 {
+			break
+		}
+	}
+	if num != 2 {
+		t.Errorf("iterated %d vertices, want 2", num)
+	}
+}
+
+func TestTangentVertex(t *testing.T) {
+	cases := []struct {
+		name       string
+		tangent    mgl32.Vec4
+		handedness float32
+		bitangent  mgl32.Vec3
+	}{
+		{"right handed", mgl32.Vec4{1, 0, 0, 1}, 1, mgl32.Vec3{0, 1, 0}},
+		{"left handed", mgl32.Vec4{1, 0, 0, -1}, -1, mgl32.Vec3{0, -1, 0}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vertex := NewTangentVertex(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{0, 0, 1}, mgl32.Vec2{0.5, 0.5}, tc.tangent, NewRigidBoneWeight(2))
+			if vertex.Position != (mgl32.Vec3{1, 2, 3}) || vertex.UVs != (mgl32.Vec2{0.5, 0.5}) || vertex.BoneWeight.Bone[0] != 2 {
+				t.Errorf("vertex = %+v", vertex)
+			}
+			if vertex.Handedness() != tc.handedness {
+				t.Errorf("handedness = %v, want %v", vertex.Handedness(), tc.handedness)
+			}
+			if !vertex.Bitangent().ApproxEqual(tc.bitangent) {
+				t.Errorf("bitangent = %v, want %v", vertex.Bitangent(), tc.bitangent)
+			}
+		})
+	}
+}
+
+func TestBoneWeightInfluences(t *testing.T) {
+	if _, err := NewBoneWeight([]int8{0, 1, 2, 3}, []float32{0.25, 0.25, 0.25, 0.25}); err == nil {
+		t.Error("expected an error for too many influences")
+	}
+
+	weight, err := NewBoneWeight([]int8{4, 7}, []float32{0.75, 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bones []int8
+	var total float32
+	for bone, value := range weight.Influences() {
+		bones = append(bones, bone)
+		total += value
+	}
+	if len(bones) != 2 || bones[0] != 4 || bones[1] != 7 || total != 1 {
+		t.Errorf("influences = %v totalling %v", bones, total)
+	}
+}
@@ -1,6 +1,10 @@
 package vvd
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+)
 
 const (
 	// MaxNumLods is the maximum number of LODs a model can have
@@ -12,17 +16,28 @@ const (
 // Vvd
 type Vvd struct {
 	// Header
-	Header header
+	Header Header
 	// Fixups
-	Fixups []fixup
+	Fixups []Fixup
 	// Vertices
-	Vertices []vertex
+	Vertices []Vertex
 	// Tangents
+	// One per vertex. W holds the bitangent handedness, see Handedness
 	Tangents []mgl32.Vec4
+	// ExtraData
+	// Raw extra vertex attributes from CS:GO era files
+	ExtraData []ExtraAttribute
+	// ExtraTexCoords
+	// Additional UV channels from ExtraData, keyed by channel number.
+	// Each slice has one entry per vertex in Vertices
+	ExtraTexCoords map[int][]mgl32.Vec2
+	// ByteOrder is the byte order the file was stored in.
+	// binary.BigEndian for Xbox 360 and PS3 (.360.vvd) files
+	ByteOrder binary.ByteOrder
 }
 
-// header
-type header struct {
+// Header is the vvd file header (vertexFileHeader_t)
+type Header struct {
 	// Id
 	Id int32
 	// Version
@@ -43,9 +58,10 @@ type header struct {
 	TangentDataStart int32
 }
 
-// fixup
-type fixup struct {
+// Fixup is a run of vertices that is used by Lod and every higher detail LOD
+type Fixup struct {
 	// Lod
+	// Coarsest LOD that uses this run
 	Lod int32
 	// SourceVertexID
 	SourceVertexID int32
@@ -53,10 +69,11 @@ type fixup struct {
 	NumVertexes int32
 }
 
-// vertex
-type vertex struct {
+// Vertex is a single skinned vertex (mstudiovertex_t).
+// Its memory layout matches the file, so it can be read and written directly.
+type Vertex struct {
 	// BoneWeight
-	BoneWeight boneWeight
+	BoneWeight BoneWeight
 	// Position
 	Position mgl32.Vec3
 	// Normal
@@ -65,8 +82,16 @@ type vertex struct {
 	UVs mgl32.Vec2
 }
 
-// boneWeight
-type boneWeight struct {
+// TangentVertex is a vertex with its tangent, which the file stores in a separate array
+type TangentVertex struct {
+	Vertex
+	// Tangent
+	// W holds the bitangent handedness, see Handedness
+	Tangent mgl32.Vec4
+}
+
+// BoneWeight holds up to MaxNumBonesPerVertex bone influences (mstudioboneweight_t)
+type BoneWeight struct {
 	// Weight
 	Weight [MaxNumBonesPerVertex]float32
 	// Bone
@@ -74,3 +99,159 @@ type boneWeight struct {
 	// NumBones
 	NumBones int8
 }
+
+// ExtraAttributeType identifies the contents of an extra vertex attribute
+type ExtraAttributeType int32
+
+const (
+	// ExtraAttributeTexCoord0 is the first extra texture coordinate channel.
+	// Channels 0-7 follow consecutively
+	ExtraAttributeTexCoord0 ExtraAttributeType = iota
+	ExtraAttributeTexCoord1
+	ExtraAttributeTexCoord2
+	ExtraAttributeTexCoord3
+	ExtraAttributeTexCoord4
+	ExtraAttributeTexCoord5
+	ExtraAttributeTexCoord6
+	ExtraAttributeTexCoord7
+)
+
+// ExtraAttribute is a single entry of the extra vertex data table
+type ExtraAttribute struct {
+	// Type
+	Type ExtraAttributeType
+	// Data
+	Data []byte
+}
+
+// extraDataHeader
+type extraDataHeader struct {
+	// Count
+	Count int32
+	// TotalBytes
+	TotalBytes int32
+}
+
+// extraDataIndex
+type extraDataIndex struct {
+	// Type
+	Type int32
+	// Offset
+	Offset int32
+	// Bytes
+	Bytes int32
+}
+
+// TexCoord returns a vertex's texture coordinate in a UV channel.
+// Channel 0 is the vertex's own UV unless the file overrides it with extra data.
+func (vvd *Vvd) TexCoord(channel int, vertex int) (mgl32.Vec2, bool) {
+	if texCoords, ok := vvd.ExtraTexCoords[channel]; ok {
+		if vertex < 0 || vertex >= len(texCoords) {
+			return mgl32.Vec2{}, false
+		}
+		return texCoords[vertex], true
+	}
+	if channel != 0 || vertex < 0 || vertex >= len(vvd.Vertices) {
+		return mgl32.Vec2{}, false
+	}
+	return vvd.Vertices[vertex].UVs, true
+}
+
+// NumTexCoordChannels returns the number of UV channels available, including the base channel
+func (vvd *Vvd) NumTexCoordChannels() int {
+	num := 1
+	for channel := range vvd.ExtraTexCoords {
+		if channel+1 > num {
+			num = channel + 1
+		}
+	}
+	return num
+}
+
+// VerticesForLOD returns the vertices used by a LOD, in the order VTX OriginalMeshVertexID
+// values for that LOD expect.
+// Files without fixups store a single array shared by every LOD, which is returned as is.
+func (vvd *Vvd) VerticesForLOD(lod int) ([]Vertex, error) {
+	if err := vvd.validateLOD(lod); err != nil {
+		return nil, err
+	}
+	if len(vvd.Fixups) == 0 {
+		return vvd.Vertices, nil
+	}
+	return applyFixups(vvd.Fixups, vvd.Vertices, lod, int(vvd.Header.NumLODVertexes[lod]))
+}
+
+// TangentsForLOD returns the tangents used by a LOD, matching VerticesForLOD
+func (vvd *Vvd) TangentsForLOD(lod int) ([]mgl32.Vec4, error) {
+	if err := vvd.validateLOD(lod); err != nil {
+		return nil, err
+	}
+	if len(vvd.Fixups) == 0 || len(vvd.Tangents) == 0 {
+		return vvd.Tangents, nil
+	}
+	return applyFixups(vvd.Fixups, vvd.Tangents, lod, int(vvd.Header.NumLODVertexes[lod]))
+}
+
+// TexCoordsForLOD returns an extra UV channel for a LOD, matching VerticesForLOD
+func (vvd *Vvd) TexCoordsForLOD(channel int, lod int) ([]mgl32.Vec2, error) {
+	if err := vvd.validateLOD(lod); err != nil {
+		return nil, err
+	}
+	texCoords, ok := vvd.ExtraTexCoords[channel]
+	if !ok {
+		return nil, fmt.Errorf("no extra texture coordinates for channel %d", channel)
+	}
+	if len(vvd.Fixups) == 0 {
+		return texCoords, nil
+	}
+	return applyFixups(vvd.Fixups, texCoords, lod, int(vvd.Header.NumLODVertexes[lod]))
+}
+
+// validateLOD checks a LOD index against the header
+func (vvd *Vvd) validateLOD(lod int) error {
+	if lod < 0 || lod >= int(vvd.Header.NumLODs) {
+		return fmt.Errorf("lod %d out of range (have %d lods)", lod, vvd.Header.NumLODs)
+	}
+	return nil
+}
+
+// applyFixups reproduces the engine's fixup walk. Each fixup copies a run of source data
+// that is used by its LOD and every higher detail LOD, so a LOD takes all runs whose
+// LOD is at least as coarse as itself.
+func applyFixups[T any](fixups []Fixup, source []T, lod int, expected int) ([]T, error) {
+	runs, err := fixupRuns(fixups, len(source), lod, expected)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]T, 0, expected)
+	for _, run := range runs {
+		out = append(out, source[run[0]:run[1]]...)
+	}
+
+	return out, nil
+}
+
+// fixupRuns returns the [from, to) source ranges that make up a LOD, checking every run
+// is within a source of numSource elements and that they add up to expected
+func fixupRuns(fixups []Fixup, numSource int, lod int, expected int) ([][2]int, error) {
+	runs := make([][2]int, 0, len(fixups))
+	total := 0
+	for i, fixup := range fixups {
+		if int(fixup.Lod) < lod {
+			continue
+		}
+		from, to := int(fixup.SourceVertexID), int(fixup.SourceVertexID)+int(fixup.NumVertexes)
+		if from < 0 || from > to || to > numSource {
+			return nil, fmt.Errorf("fixup %d references vertices [%d:%d] out of range (have %d)", i, from, to, numSource)
+		}
+		runs = append(runs, [2]int{from, to})
+		total += to - from
+	}
+
+	if total != expected {
+		return nil, fmt.Errorf("fixups for lod %d produced %d vertices, header expects %d", lod, total, expected)
+	}
+
+	return runs, nil
+}